@@ -0,0 +1,52 @@
+package dhcp4client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+)
+
+const (
+	arpLen       = 28
+	arpHTypeEth  = 1
+	arpPTypeIPv4 = 0x0800
+	arpRequest   = 1
+)
+
+//https://tools.ietf.org/html/rfc5227#section-2.1.1 an ARP Probe is an ARP
+//Request for the address with our hardware address as the sender and a zero
+//sender IP and target hardware address.
+func arpProbe(hardwareAddr net.HardwareAddr, ip net.IP) []byte {
+	pkt := make([]byte, arpLen)
+	binary.BigEndian.PutUint16(pkt[0:2], arpHTypeEth)
+	binary.BigEndian.PutUint16(pkt[2:4], arpPTypeIPv4)
+	pkt[4] = 6
+	pkt[5] = 4
+	binary.BigEndian.PutUint16(pkt[6:8], arpRequest)
+	copy(pkt[8:14], hardwareAddr)
+	copy(pkt[24:28], ip.To4())
+	return pkt
+}
+
+//https://tools.ietf.org/html/rfc5227#section-2.1.1 there's a conflict if
+//another host sends any ARP packet with the address as the sender IP, or an
+//ARP Probe with it as the target IP.
+func arpConflict(pkt []byte, hardwareAddr net.HardwareAddr, ip net.IP) bool {
+	if len(pkt) < arpLen || binary.BigEndian.Uint16(pkt[0:2]) != arpHTypeEth || binary.BigEndian.Uint16(pkt[2:4]) != arpPTypeIPv4 || pkt[4] != 6 || pkt[5] != 4 {
+		return false
+	}
+
+	senderHardwareAddr := pkt[8:14]
+	senderIP := net.IP(pkt[14:18])
+	targetIP := net.IP(pkt[24:28])
+
+	if bytes.Equal(senderHardwareAddr, hardwareAddr) {
+		return false
+	}
+
+	if senderIP.Equal(ip) {
+		return true
+	}
+
+	return binary.BigEndian.Uint16(pkt[6:8]) == arpRequest && senderIP.Equal(net.IPv4zero) && targetIP.Equal(ip)
+}
@@ -0,0 +1,179 @@
+package dhcp4client
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+//https://tools.ietf.org/html/rfc5227#section-1.1 protocol constants.
+const (
+	probeWait    = time.Second
+	probeNum     = 3
+	probeMin     = time.Second
+	probeMax     = time.Second * 2
+	announceWait = time.Second * 2
+)
+
+//ARPProber detects address conflicts by RFC 5227 ARP Probing over AF_PACKET.
+type ARPProber struct {
+	file         *os.File //Non-blocking, so ProbeContext can interrupt a read with a deadline.
+	conn         syscall.RawConn
+	ifindex      int
+	hardwareAddr net.HardwareAddr
+
+	ProbeWait    time.Duration //Upper limit on the random delay before the first probe.
+	ProbeNum     int           //Number of probes to send.
+	ProbeMin     time.Duration //Lower limit on the random delay between probes.
+	ProbeMax     time.Duration //Upper limit on the random delay between probes.
+	AnnounceWait time.Duration //Time to listen after the last probe.
+}
+
+func NewARPProber(ifindex int, hardwareAddr net.HardwareAddr) (*ARPProber, error) {
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM|unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC, int(swap16(unix.ETH_P_ARP)))
+	if err != nil {
+		return nil, err
+	}
+
+	addr := unix.SockaddrLinklayer{
+		Ifindex:  ifindex,
+		Protocol: swap16(unix.ETH_P_ARP),
+	}
+
+	if err = unix.Bind(fd, &addr); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+
+	file := os.NewFile(uintptr(fd), "arp")
+	conn, err := file.SyscallConn()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &ARPProber{
+		file:         file,
+		conn:         conn,
+		ifindex:      ifindex,
+		hardwareAddr: hardwareAddr,
+		ProbeWait:    probeWait,
+		ProbeNum:     probeNum,
+		ProbeMin:     probeMin,
+		ProbeMax:     probeMax,
+		AnnounceWait: announceWait,
+	}, nil
+}
+
+func (p *ARPProber) Close() error {
+	return p.file.Close()
+}
+
+//Probe for the address, returns true if another host replied to (or is
+//probing for) it.
+func (p *ARPProber) Probe(ip net.IP) (bool, error) {
+	return p.ProbeContext(context.Background(), ip)
+}
+
+//Probe, giving up as soon as the context is done.
+func (p *ARPProber) ProbeContext(ctx context.Context, ip net.IP) (bool, error) {
+	ip = ip.To4()
+
+	if conflict, err := p.listen(ctx, ip, time.Now().Add(randomDuration(0, p.ProbeWait))); conflict || err != nil {
+		return conflict, err
+	}
+
+	for i := 0; i < p.ProbeNum; i++ {
+		if err := p.sendProbe(ip); err != nil {
+			return false, err
+		}
+
+		wait := randomDuration(p.ProbeMin, p.ProbeMax)
+		if i == p.ProbeNum-1 {
+			wait = p.AnnounceWait
+		}
+
+		if conflict, err := p.listen(ctx, ip, time.Now().Add(wait)); conflict || err != nil {
+			return conflict, err
+		}
+	}
+
+	return false, nil
+}
+
+//Broadcast an ARP Probe for the address.
+func (p *ARPProber) sendProbe(ip net.IP) error {
+	lladdr := unix.SockaddrLinklayer{
+		Ifindex:  p.ifindex,
+		Protocol: swap16(unix.ETH_P_ARP),
+		Halen:    uint8(len(bcastMAC)),
+	}
+	copy(lladdr.Addr[:], bcastMAC)
+
+	pkt := arpProbe(p.hardwareAddr, ip)
+
+	var sendErr error
+	err := p.conn.Write(func(fd uintptr) bool {
+		sendErr = unix.Sendto(int(fd), pkt, 0, &lladdr)
+		return sendErr != unix.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
+
+//Listen for ARP packets until the deadline, returns true on a conflict or the
+//context's error if it's done first.
+func (p *ARPProber) listen(ctx context.Context, ip net.IP, until time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	if err := p.file.SetReadDeadline(until); err != nil {
+		return false, err
+	}
+	defer interruptDeadline(ctx, p.file)()
+
+	pkt := make([]byte, 128)
+	for {
+		var n int
+		var recvErr error
+		err := p.conn.Read(func(fd uintptr) bool {
+			n, _, recvErr = unix.Recvfrom(int(fd), pkt, 0)
+			return recvErr != unix.EAGAIN
+		})
+		if err == nil {
+			err = recvErr
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return false, nil
+			}
+			if err == unix.EINTR {
+				continue
+			}
+			return false, err
+		}
+
+		if arpConflict(pkt[:n], p.hardwareAddr, ip) {
+			return true, nil
+		}
+	}
+}
+
+func randomDuration(min time.Duration, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+	return min + time.Duration(rand.Int63n(int64(max-min)))
+}
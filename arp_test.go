@@ -0,0 +1,62 @@
+package dhcp4client
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+var (
+	testARPHardwareAddr  = net.HardwareAddr{0x08, 0x00, 0x27, 0x00, 0xA8, 0xE8}
+	otherARPHardwareAddr = net.HardwareAddr{0x08, 0x00, 0x27, 0x00, 0xA8, 0xE9}
+)
+
+//An ARP packet from senderHardwareAddr/senderIP for targetIP.
+func testARPPacket(operation byte, senderHardwareAddr net.HardwareAddr, senderIP net.IP, targetIP net.IP) []byte {
+	pkt := []byte{0, 1, 8, 0, 6, 4, 0, operation}
+	pkt = append(pkt, senderHardwareAddr...)
+	pkt = append(pkt, senderIP.To4()...)
+	pkt = append(pkt, make([]byte, 6)...)
+	return append(pkt, targetIP.To4()...)
+}
+
+func Test_ARPProbe(test *testing.T) {
+	ip := net.IPv4(192, 168, 1, 100)
+
+	expected := testARPPacket(arpRequest, testARPHardwareAddr, net.IPv4zero, ip)
+	if pkt := arpProbe(testARPHardwareAddr, ip); !bytes.Equal(pkt, expected) {
+		test.Errorf("Probe:%v, expected %v", pkt, expected)
+	}
+}
+
+func Test_ARPConflict(test *testing.T) {
+	ip := net.IPv4(192, 168, 1, 100)
+	otherIP := net.IPv4(192, 168, 1, 101)
+
+	truncated := testARPPacket(2, otherARPHardwareAddr, ip, otherIP)[:arpLen-1]
+	notIPv4 := testARPPacket(2, otherARPHardwareAddr, ip, otherIP)
+	notIPv4[2] = 0x86
+
+	tests := []struct {
+		name     string
+		pkt      []byte
+		conflict bool
+	}{
+		{"reply from the address", testARPPacket(2, otherARPHardwareAddr, ip, otherIP), true},
+		{"request from the address", testARPPacket(arpRequest, otherARPHardwareAddr, ip, otherIP), true},
+		{"announcement of the address", testARPPacket(arpRequest, otherARPHardwareAddr, ip, ip), true},
+		{"probe for the address", testARPPacket(arpRequest, otherARPHardwareAddr, net.IPv4zero, ip), true},
+		{"our own probe", arpProbe(testARPHardwareAddr, ip), false},
+		{"probe for another address", testARPPacket(arpRequest, otherARPHardwareAddr, net.IPv4zero, otherIP), false},
+		{"request for the address", testARPPacket(arpRequest, otherARPHardwareAddr, otherIP, ip), false},
+		{"reply to a probe for the address", testARPPacket(2, otherARPHardwareAddr, net.IPv4zero, ip), false},
+		{"truncated", truncated, false},
+		{"not IPv4", notIPv4, false},
+	}
+
+	for _, t := range tests {
+		if conflict := arpConflict(t.pkt, testARPHardwareAddr, ip.To4()); conflict != t.conflict {
+			test.Errorf("%s: conflict %v, expected %v", t.name, conflict, t.conflict)
+		}
+	}
+}
@@ -2,12 +2,16 @@ package dhcp4client
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"hash/fnv"
+	"log"
 	"math/rand"
 	"net"
 	"sync"
-	"syscall"
+	"sync/atomic"
 	"time"
 
 	"github.com/d2g/dhcp4"
@@ -18,12 +22,29 @@ const (
 )
 
 type Client struct {
-	hardwareAddr  net.HardwareAddr //The HardwareAddr to send in the request.
-	ignoreServers []net.IP         //List of Servers to Ignore requests from.
-	timeout       time.Duration    //Time before we timeout.
-	broadcast     bool             //Set the Bcast flag in BOOTP Flags
-	connection    ConnectionInt    //The Connection Method to use
-	generateXID   func([]byte)     //Function Used to Generate a XID
+	hardwareAddr   net.HardwareAddr     //The HardwareAddr to send in the request.
+	ignoreServers  []net.IP             //List of Servers to Ignore requests from.
+	timeout        time.Duration        //Time before we timeout.
+	broadcast      bool                 //Set the Bcast flag in BOOTP Flags
+	connection     ConnectionInt        //The Connection Method to use
+	generateXID    func([]byte)         //Function Used to Generate a XID
+	retransmission RetransmissionPolicy //When to resend unanswered packets.
+	offerWindow    time.Duration        //How long to gather Offers for.
+	offerSelector  OfferSelector        //Picks the Offer to Request, nil to take the first.
+	conflict       ConflictDetector     //Checks the address isn't in use before accepting it.
+	declineBackoff time.Duration        //Time to wait after a Decline before starting again.
+	rapidCommit    bool                 //Ask for (and accept) a two message exchange.
+	requested      []dhcp4.OptionCode   //Options to ask the server for (Parameter Request List).
+	clientID       []byte               //Client Identifier to send in every packet.
+	hostname       string               //Host Name (option 12) to send.
+	fqdn           []byte               //Encoded Client FQDN (option 81) to send.
+	vendorClass    string               //Vendor Class Identifier (option 60) to send.
+	maxMessageSize uint16               //Largest DHCP message we'll accept (option 57), 0 for the default.
+	denyServers    []*net.IPNet         //Networks to reject servers from.
+	allowServers   []*net.IPNet         //Networks to only accept servers from, nil for any.
+	logger         *log.Logger          //Where to log rejected packets, nil to not.
+	rejected       atomic.Uint64        //Number of packets rejected by the server filters.
+	verifyServerID bool                 //Only accept Acknowledgements from the server Requested from.
 }
 
 //Abstracts the type of underlying socket used
@@ -34,10 +55,50 @@ type ConnectionInt interface {
 	SetReadTimeout(t time.Duration) error
 }
 
+//Implemented by connections which can send to a particular server, used to
+//unicast Renewals and Releases. Otherwise they're sent with Write.
+type UnicastWriter interface {
+	//Send to dstIP at dstMAC, a nil dstIP broadcasts. Without a dstMAC the
+	//connection finds the hardware address itself, broadcasting if it can't.
+	WriteTo(packet []byte, dstIP net.IP, dstMAC net.HardwareAddr) error
+}
+
+//Implemented by connections which can read DHCP messages larger than MaxDHCPLen.
+type MaxMessageSizer interface {
+	SetMaxMessageSize(n int) error
+}
+
+//Implemented by connections whose ReadFrom can be interrupted by a deadline in
+//the past, letting the Context methods return as soon as the context is done.
+//Otherwise they return when the current read times out.
+type ReadDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+//TruncatedError records a DHCP packet which didn't fit in the read buffer.
+type TruncatedError struct {
+	Max int
+}
+
+func (te *TruncatedError) Error() string {
+	return fmt.Sprintf("DHCP packet truncated, larger than %d bytes", te.Max)
+}
+
+//Was the packet too large to read. packetSock reads every IPv4 packet on the
+//interface so most of these aren't for us.
+func isTruncated(err error) bool {
+	var truncatedError *TruncatedError
+	return errors.As(err, &truncatedError)
+}
+
 func New(options ...func(*Client) error) (*Client, error) {
 	c := Client{
 		timeout:   time.Second * 10,
 		broadcast: true,
+		//https://tools.ietf.org/html/rfc2131#section-3.1 after a DHCPDECLINE the
+		//client SHOULD wait a minimum of ten seconds before restarting.
+		declineBackoff: time.Second * 10,
+		verifyServerID: true,
 	}
 
 	err := c.SetOption(options...)
@@ -74,6 +135,10 @@ func New(options ...func(*Client) error) (*Client, error) {
 		c.connection = conn
 	}
 
+	if err := c.sizeConnection(); err != nil {
+		return nil, err
+	}
+
 	return &c, nil
 }
 
@@ -114,9 +179,158 @@ func Broadcast(b bool) func(*Client) error {
 	}
 }
 
+//The connection is sized for the MaxMessageSize if one's been set.
 func Connection(conn ConnectionInt) func(*Client) error {
 	return func(c *Client) error {
+		previous := c.connection
 		c.connection = conn
+		if err := c.sizeConnection(); err != nil {
+			c.connection = previous
+			return err
+		}
+		return nil
+	}
+}
+
+//Resend packets which haven't been answered according to the policy.
+//Without one each packet is sent once and we wait for Timeout.
+func Retransmission(p RetransmissionPolicy) func(*Client) error {
+	return func(c *Client) error {
+		c.retransmission = p
+		return nil
+	}
+}
+
+//Gather all the Offers received within the window after the first and
+//Request the one picked by the selector.
+func SelectOffer(window time.Duration, selector OfferSelector) func(*Client) error {
+	return func(c *Client) error {
+		c.offerWindow = window
+		c.offerSelector = selector
+		return nil
+	}
+}
+
+//Probe the address in each Acknowledgement, Declining it and starting again if
+//it's already in use.
+func ConflictDetection(d ConflictDetector) func(*Client) error {
+	return func(c *Client) error {
+		c.conflict = d
+		return nil
+	}
+}
+
+//Time to wait after Declining an address before starting again.
+func DeclineBackoff(t time.Duration) func(*Client) error {
+	return func(c *Client) error {
+		c.declineBackoff = t
+		return nil
+	}
+}
+
+//Add Rapid Commit (RFC 4039) to Discover Packets, allowing servers which
+//support it to Acknowledge them straight away.
+func RapidCommit(r bool) func(*Client) error {
+	return func(c *Client) error {
+		c.rapidCommit = r
+		return nil
+	}
+}
+
+//Ask the server for these options (option 55, the Parameter Request List)
+//in Discover, Request and Inform Packets.
+func RequestedOptions(o []dhcp4.OptionCode) func(*Client) error {
+	return func(c *Client) error {
+		c.requested = o
+		return nil
+	}
+}
+
+//Identify ourselves with a Client Identifier (option 61) in every packet
+//rather than just by the HardwareAddr.
+//See NodeSpecificClientID to build an RFC 4361 identifier.
+func ClientID(id []byte) func(*Client) error {
+	return func(c *Client) error {
+		c.clientID = id
+		return nil
+	}
+}
+
+//Send our Host Name (option 12), unless we've an FQDN to send instead.
+func Hostname(h string) func(*Client) error {
+	return func(c *Client) error {
+		if len(h) < 1 || len(h) > 255 {
+			return fmt.Errorf("invalid hostname %q", h)
+		}
+		c.hostname = h
+		return nil
+	}
+}
+
+//Send our Fully Qualified Domain Name (option 81) so the server can update DNS.
+//A name ending in "." is fully qualified, otherwise the server completes it.
+//The Host Name isn't sent with it.
+func FQDN(name string, flags FQDNFlags) func(*Client) error {
+	return func(c *Client) error {
+		fqdn, err := ClientFQDN{Flags: flags, Name: name}.encode()
+		if err != nil {
+			return err
+		}
+		c.fqdn = fqdn
+		return nil
+	}
+}
+
+//Send a Vendor Class Identifier (option 60), servers use this to choose the
+//Vendor-Specific Information (option 43) to send us.
+func VendorClass(v string) func(*Client) error {
+	return func(c *Client) error {
+		if len(v) < 1 || len(v) > 255 {
+			return fmt.Errorf("invalid vendor class %q", v)
+		}
+		c.vendorClass = v
+		return nil
+	}
+}
+
+//Accept DHCP messages up to n bytes, telling the server (option 57) and
+//sizing the connection's read buffers to match.
+//The connection must be a MaxMessageSizer.
+func MaxMessageSize(n uint16) func(*Client) error {
+	return func(c *Client) error {
+		//https://tools.ietf.org/html/rfc2132#section-9.10
+		if n < MaxDHCPLen {
+			return fmt.Errorf("maximum message size %d is less than %d", n, MaxDHCPLen)
+		}
+
+		previous := c.maxMessageSize
+		c.maxMessageSize = n
+		if err := c.sizeConnection(); err != nil {
+			c.maxMessageSize = previous
+			return err
+		}
+		return nil
+	}
+}
+
+//Size the connection's read buffers for the MaxMessageSize, if there are both.
+func (c *Client) sizeConnection() error {
+	if c.maxMessageSize == 0 || c.connection == nil {
+		return nil
+	}
+
+	sizer, ok := c.connection.(MaxMessageSizer)
+	if !ok {
+		return fmt.Errorf("connection can't read messages larger than %d bytes", MaxDHCPLen)
+	}
+	return sizer.SetMaxMessageSize(int(c.maxMessageSize))
+}
+
+//Log packets rejected by the server filters or ignored because they don't
+//answer our requests.
+func Log(l *log.Logger) func(*Client) error {
+	return func(c *Client) error {
+		c.logger = l
 		return nil
 	}
 }
@@ -145,6 +359,7 @@ func (c *Client) SendDiscoverPacket() (dhcp4.Packet, error) {
 }
 
 // TimeoutError records a timeout when waiting for a DHCP packet.
+// Both of the connections provided report their read timeouts with it.
 type TimeoutError struct {
 	Timeout time.Duration
 }
@@ -153,41 +368,145 @@ func (te *TimeoutError) Error() string {
 	return fmt.Sprintf("no DHCP packet received within %v", te.Timeout)
 }
 
+//Did we give up waiting for a packet, either on our timeout or the sockets.
+func isTimeout(err error) bool {
+	var timeoutError *TimeoutError
+	if errors.As(err, &timeoutError) {
+		return true
+	}
+	if networkError, ok := err.(net.Error); ok && networkError.Timeout() {
+		return true
+	}
+	return false
+}
+
+//Read a packet waiting up to timeout, returning the context's error if it's
+//done first.
+func (c *Client) readFrom(ctx context.Context, timeout time.Duration) ([]byte, net.IP, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	c.connection.SetReadTimeout(timeout)
+	defer c.interruptRead(ctx)()
+
+	readBuffer, source, err := c.connection.ReadFrom()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		if isTimeout(err) {
+			return nil, nil, &TimeoutError{Timeout: timeout}
+		}
+		return nil, nil, err
+	}
+	if len(readBuffer) < optionsStart {
+		return nil, nil, malformed(fmt.Errorf("%d byte packet is too short", len(readBuffer)))
+	}
+	return readBuffer, source, nil
+}
+
+//Interrupt the connection's ReadFrom if the context is done before the
+//returned stop function is called.
+func (c *Client) interruptRead(ctx context.Context) (stop func()) {
+	deadliner, ok := c.connection.(ReadDeadliner)
+	if !ok {
+		return func() {}
+	}
+	return interruptDeadline(ctx, deadliner)
+}
+
+//Set a deadline in the past, interrupting any read, if the context is done
+//before the returned stop function is called.
+func interruptDeadline(ctx context.Context, deadliner ReadDeadliner) (stop func()) {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+
+	stopped := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		select {
+		case <-ctx.Done():
+			deadliner.SetReadDeadline(time.Unix(1, 0))
+		case <-stopped:
+		}
+	}()
+
+	return func() {
+		close(stopped)
+		<-finished
+	}
+}
+
 //Retreive Offer...
 //Wait for the offer for a specific Discovery Packet.
+//With RapidCommit this may be an Acknowledgement.
 func (c *Client) GetOffer(discoverPacket *dhcp4.Packet) (dhcp4.Packet, error) {
+	return c.GetOfferContext(context.Background(), discoverPacket)
+}
+
+//GetOffer, giving up as soon as the context is done.
+func (c *Client) GetOfferContext(ctx context.Context, discoverPacket *dhcp4.Packet) (dhcp4.Packet, error) {
+	return c.getOffer(ctx, discoverPacket, c.timeout)
+}
+
+//Wait up to timeout for the offer.
+//Packets too large to read are skipped, if nothing else arrives the
+//TimeoutError is returned wrapping the *TruncatedError.
+func (c *Client) getOffer(ctx context.Context, discoverPacket *dhcp4.Packet, timeout time.Duration) (dhcp4.Packet, error) {
 	start := time.Now()
+	var truncated error
 
 	for {
-		timeout := c.timeout - time.Since(start)
-		if timeout < 0 {
-			return dhcp4.Packet{}, &TimeoutError{Timeout: c.timeout}
+		remaining := timeout - time.Since(start)
+		if remaining < 0 {
+			return dhcp4.Packet{}, waitTimeout(timeout, truncated)
 		}
 
-		c.connection.SetReadTimeout(timeout)
-		readBuffer, source, err := c.connection.ReadFrom()
+		readBuffer, source, err := c.readFrom(ctx, remaining)
 		if err != nil {
-			if errno, ok := err.(syscall.Errno); ok && errno == syscall.EAGAIN {
-				return dhcp4.Packet{}, &TimeoutError{Timeout: c.timeout}
+			if errors.Is(err, ErrMalformedReply) {
+				continue
+			}
+			if isTruncated(err) {
+				c.logIgnored(source, err)
+				truncated = err
+				continue
+			}
+			if _, ok := err.(*TimeoutError); ok {
+				return dhcp4.Packet{}, waitTimeout(timeout, truncated)
 			}
 			return dhcp4.Packet{}, err
 		}
 
 		offerPacket := dhcp4.Packet(readBuffer)
-		offerPacketOptions := offerPacket.ParseOptions()
+		offerPacketOptions := ParseReplyOptions(offerPacket)
 
-		// Ignore Servers in my Ignore list
-		for _, ignoreServer := range c.ignoreServers {
-			if source.Equal(ignoreServer) {
-				continue
-			}
+		if len(offerPacketOptions[dhcp4.OptionDHCPMessageType]) < 1 || !bytes.Equal(discoverPacket.XId(), offerPacket.XId()) {
+			continue
+		}
 
-			if offerPacket.SIAddr().Equal(ignoreServer) {
-				continue
-			}
+		// Ignore Servers in my Ignore list (and the other filters)
+		if c.rejectServer(source, offerPacket, offerPacketOptions) {
+			continue
 		}
 
-		if len(offerPacketOptions[dhcp4.OptionDHCPMessageType]) < 1 || dhcp4.MessageType(offerPacketOptions[dhcp4.OptionDHCPMessageType][0]) != dhcp4.Offer || !bytes.Equal(discoverPacket.XId(), offerPacket.XId()) {
+		if err := c.checkReply(discoverPacket, offerPacket, offerPacketOptions); err != nil {
+			c.logIgnored(source, err)
+			continue
+		}
+
+		switch dhcp4.MessageType(offerPacketOptions[dhcp4.OptionDHCPMessageType][0]) {
+		case dhcp4.Offer:
+		case dhcp4.ACK:
+			//https://tools.ietf.org/html/rfc4039#section-4 with Rapid Commit the
+			//server can Acknowledge the Discover straight away.
+			if _, ok := offerPacketOptions[OptionRapidCommit]; !ok || !c.rapidCommit {
+				continue
+			}
+		default:
 			continue
 		}
 
@@ -207,38 +526,62 @@ func (c *Client) SendRequest(offerPacket *dhcp4.Packet) (dhcp4.Packet, error) {
 //Retreive Acknowledgement
 //Wait for the offer for a specific Request Packet.
 func (c *Client) GetAcknowledgement(requestPacket *dhcp4.Packet) (dhcp4.Packet, error) {
+	return c.GetAcknowledgementContext(context.Background(), requestPacket)
+}
+
+//GetAcknowledgement, giving up as soon as the context is done.
+func (c *Client) GetAcknowledgementContext(ctx context.Context, requestPacket *dhcp4.Packet) (dhcp4.Packet, error) {
+	return c.getAcknowledgement(ctx, requestPacket, c.timeout)
+}
+
+//Wait up to timeout for the acknowledgement.
+//Replies from the wrong server and packets too large to read are skipped, if
+//nothing else arrives the TimeoutError is returned wrapping ErrServerMismatch
+//(or the *TruncatedError).
+func (c *Client) getAcknowledgement(ctx context.Context, requestPacket *dhcp4.Packet, timeout time.Duration) (dhcp4.Packet, error) {
 	start := time.Now()
+	var mismatch error
+	var truncated error
 
 	for {
-		timeout := c.timeout - time.Since(start)
-		if timeout < 0 {
-			return dhcp4.Packet{}, &TimeoutError{Timeout: c.timeout}
+		remaining := timeout - time.Since(start)
+		if remaining < 0 {
+			return dhcp4.Packet{}, waitTimeout(timeout, ignoredReply(mismatch, truncated))
 		}
 
-		c.connection.SetReadTimeout(timeout)
-		readBuffer, source, err := c.connection.ReadFrom()
+		readBuffer, source, err := c.readFrom(ctx, remaining)
 		if err != nil {
-			if errno, ok := err.(syscall.Errno); ok && errno == syscall.EAGAIN {
-				return dhcp4.Packet{}, &TimeoutError{Timeout: c.timeout}
+			if errors.Is(err, ErrMalformedReply) {
+				continue
+			}
+			if isTruncated(err) {
+				c.logIgnored(source, err)
+				truncated = err
+				continue
+			}
+			if _, ok := err.(*TimeoutError); ok {
+				return dhcp4.Packet{}, waitTimeout(timeout, ignoredReply(mismatch, truncated))
 			}
 			return dhcp4.Packet{}, err
 		}
 
 		acknowledgementPacket := dhcp4.Packet(readBuffer)
-		acknowledgementPacketOptions := acknowledgementPacket.ParseOptions()
+		acknowledgementPacketOptions := ParseReplyOptions(acknowledgementPacket)
 
-		// Ignore Servers in my Ignore list
-		for _, ignoreServer := range c.ignoreServers {
-			if source.Equal(ignoreServer) {
-				continue
-			}
+		if !bytes.Equal(requestPacket.XId(), acknowledgementPacket.XId()) || len(acknowledgementPacketOptions[dhcp4.OptionDHCPMessageType]) < 1 || (dhcp4.MessageType(acknowledgementPacketOptions[dhcp4.OptionDHCPMessageType][0]) != dhcp4.ACK && dhcp4.MessageType(acknowledgementPacketOptions[dhcp4.OptionDHCPMessageType][0]) != dhcp4.NAK) {
+			continue
+		}
 
-			if acknowledgementPacket.SIAddr().Equal(ignoreServer) {
-				continue
-			}
+		// Ignore Servers in my Ignore list (and the other filters)
+		if c.rejectServer(source, acknowledgementPacket, acknowledgementPacketOptions) {
+			continue
 		}
 
-		if !bytes.Equal(requestPacket.XId(), acknowledgementPacket.XId()) || len(acknowledgementPacketOptions[dhcp4.OptionDHCPMessageType]) < 1 || (dhcp4.MessageType(acknowledgementPacketOptions[dhcp4.OptionDHCPMessageType][0]) != dhcp4.ACK && dhcp4.MessageType(acknowledgementPacketOptions[dhcp4.OptionDHCPMessageType][0]) != dhcp4.NAK) {
+		if err := c.checkReply(requestPacket, acknowledgementPacket, acknowledgementPacketOptions); err != nil {
+			c.logIgnored(source, err)
+			if errors.Is(err, ErrServerMismatch) {
+				mismatch = err
+			}
 			continue
 		}
 
@@ -259,6 +602,15 @@ func (c *Client) SendPacket(packet dhcp4.Packet) error {
 	return c.connection.Write(packet)
 }
 
+//Send a DHCP Packet to the server at ip, or broadcast it if the connection
+//isn't a UnicastWriter or ip is nil.
+func (c *Client) SendPacketTo(packet dhcp4.Packet, ip net.IP) error {
+	if unicast, ok := c.connection.(UnicastWriter); ok && len(ip) > 0 {
+		return unicast.WriteTo(packet, ip, nil)
+	}
+	return c.connection.Write(packet)
+}
+
 //Create Discover Packet
 func (c *Client) DiscoverPacket() dhcp4.Packet {
 	messageid := make([]byte, 4)
@@ -270,13 +622,64 @@ func (c *Client) DiscoverPacket() dhcp4.Packet {
 	packet.SetBroadcast(c.broadcast)
 
 	packet.AddOption(dhcp4.OptionDHCPMessageType, []byte{byte(dhcp4.Discover)})
+	if c.rapidCommit {
+		packet.AddOption(OptionRapidCommit, []byte{})
+	}
+	c.addClientOptions(&packet)
 	//packet.PadToMinSize()
 	return packet
 }
 
+//Add the options configured on the Client to a Discover, Request or Inform Packet.
+func (c *Client) addClientOptions(packet *dhcp4.Packet) {
+	c.addClientID(packet)
+
+	//https://tools.ietf.org/html/rfc4702#section-3.1 a client sending the
+	//Client FQDN must not also send the Host Name.
+	if len(c.fqdn) > 0 {
+		packet.AddOption(OptionClientFQDN, c.fqdn)
+	} else if c.hostname != "" {
+		packet.AddOption(dhcp4.OptionHostName, []byte(c.hostname))
+	}
+
+	if c.vendorClass != "" {
+		packet.AddOption(dhcp4.OptionVendorClassIdentifier, []byte(c.vendorClass))
+	}
+
+	if c.maxMessageSize > 0 {
+		size := make([]byte, 2)
+		binary.BigEndian.PutUint16(size, c.maxMessageSize)
+		packet.AddOption(dhcp4.OptionMaximumDHCPMessageSize, size)
+	}
+
+	if len(c.requested) > 0 {
+		parameters := make([]byte, len(c.requested))
+		for i, code := range c.requested {
+			parameters[i] = byte(code)
+		}
+		packet.AddOption(dhcp4.OptionParameterRequestList, parameters)
+	}
+}
+
+//How we're identified to the server, the Client Identifier or without one the
+//HardwareAddr.
+func (c *Client) identifier() []byte {
+	if len(c.clientID) > 0 {
+		return c.clientID
+	}
+	return c.hardwareAddr
+}
+
+//Add the Client Identifier, if there is one, to any Packet.
+func (c *Client) addClientID(packet *dhcp4.Packet) {
+	if len(c.clientID) > 0 {
+		packet.AddOption(dhcp4.OptionClientIdentifier, c.clientID)
+	}
+}
+
 //Create Request Packet
 func (c *Client) RequestPacket(offerPacket *dhcp4.Packet) dhcp4.Packet {
-	offerOptions := offerPacket.ParseOptions()
+	offerOptions := ParseReplyOptions(*offerPacket)
 
 	packet := dhcp4.NewPacket(dhcp4.BootRequest)
 	packet.SetCHAddr(c.hardwareAddr)
@@ -289,6 +692,7 @@ func (c *Client) RequestPacket(offerPacket *dhcp4.Packet) dhcp4.Packet {
 	packet.AddOption(dhcp4.OptionDHCPMessageType, []byte{byte(dhcp4.Request)})
 	packet.AddOption(dhcp4.OptionRequestedIPAddress, (offerPacket.YIAddr()).To4())
 	packet.AddOption(dhcp4.OptionServerIdentifier, offerOptions[dhcp4.OptionServerIdentifier])
+	c.addClientOptions(&packet)
 
 	return packet
 }
@@ -298,7 +702,7 @@ func (c *Client) RenewalRequestPacket(acknowledgement *dhcp4.Packet) dhcp4.Packe
 	messageid := make([]byte, 4)
 	c.generateXID(messageid)
 
-	acknowledgementOptions := acknowledgement.ParseOptions()
+	acknowledgementOptions := ParseReplyOptions(*acknowledgement)
 
 	packet := dhcp4.NewPacket(dhcp4.BootRequest)
 	packet.SetCHAddr(acknowledgement.CHAddr())
@@ -311,6 +715,61 @@ func (c *Client) RenewalRequestPacket(acknowledgement *dhcp4.Packet) dhcp4.Packe
 	packet.AddOption(dhcp4.OptionDHCPMessageType, []byte{byte(dhcp4.Request)})
 	packet.AddOption(dhcp4.OptionRequestedIPAddress, (acknowledgement.YIAddr()).To4())
 	packet.AddOption(dhcp4.OptionServerIdentifier, acknowledgementOptions[dhcp4.OptionServerIdentifier])
+	c.addClientOptions(&packet)
+
+	return packet
+}
+
+//Create Request Packet For a Rebind
+//Unlike a Renew there's no Server Identifier so any server can extend the Lease.
+func (c *Client) RebindRequestPacket(lease Lease) dhcp4.Packet {
+	messageid := make([]byte, 4)
+	c.generateXID(messageid)
+
+	packet := dhcp4.NewPacket(dhcp4.BootRequest)
+	packet.SetCHAddr(c.hardwareAddr)
+
+	packet.SetXId(messageid)
+	packet.SetCIAddr(lease.FixedAddress)
+
+	packet.SetBroadcast(c.broadcast)
+	packet.AddOption(dhcp4.OptionDHCPMessageType, []byte{byte(dhcp4.Request)})
+	c.addClientOptions(&packet)
+
+	return packet
+}
+
+//Create Request Packet to reclaim a previously held Lease (INIT-REBOOT)
+func (c *Client) InitRebootPacket(lease Lease) dhcp4.Packet {
+	messageid := make([]byte, 4)
+	c.generateXID(messageid)
+
+	packet := dhcp4.NewPacket(dhcp4.BootRequest)
+	packet.SetCHAddr(c.hardwareAddr)
+	packet.SetXId(messageid)
+
+	packet.SetBroadcast(c.broadcast)
+	packet.AddOption(dhcp4.OptionDHCPMessageType, []byte{byte(dhcp4.Request)})
+	packet.AddOption(dhcp4.OptionRequestedIPAddress, lease.FixedAddress.To4())
+	c.addClientOptions(&packet)
+
+	return packet
+}
+
+//Create Inform Packet to ask for configuration for an address we already have
+func (c *Client) InformPacket(ip net.IP) dhcp4.Packet {
+	messageid := make([]byte, 4)
+	c.generateXID(messageid)
+
+	packet := dhcp4.NewPacket(dhcp4.BootRequest)
+	packet.SetCHAddr(c.hardwareAddr)
+
+	packet.SetXId(messageid)
+	packet.SetCIAddr(ip)
+
+	packet.SetBroadcast(c.broadcast)
+	packet.AddOption(dhcp4.OptionDHCPMessageType, []byte{byte(dhcp4.Inform)})
+	c.addClientOptions(&packet)
 
 	return packet
 }
@@ -320,7 +779,7 @@ func (c *Client) ReleasePacket(acknowledgement *dhcp4.Packet) dhcp4.Packet {
 	messageid := make([]byte, 4)
 	c.generateXID(messageid)
 
-	acknowledgementOptions := acknowledgement.ParseOptions()
+	acknowledgementOptions := ParseReplyOptions(*acknowledgement)
 
 	packet := dhcp4.NewPacket(dhcp4.BootRequest)
 	packet.SetCHAddr(acknowledgement.CHAddr())
@@ -330,6 +789,7 @@ func (c *Client) ReleasePacket(acknowledgement *dhcp4.Packet) dhcp4.Packet {
 
 	packet.AddOption(dhcp4.OptionDHCPMessageType, []byte{byte(dhcp4.Release)})
 	packet.AddOption(dhcp4.OptionServerIdentifier, acknowledgementOptions[dhcp4.OptionServerIdentifier])
+	c.addClientID(&packet)
 
 	return packet
 }
@@ -339,7 +799,7 @@ func (c *Client) DeclinePacket(acknowledgement *dhcp4.Packet) dhcp4.Packet {
 	messageid := make([]byte, 4)
 	c.generateXID(messageid)
 
-	acknowledgementOptions := acknowledgement.ParseOptions()
+	acknowledgementOptions := ParseReplyOptions(*acknowledgement)
 
 	packet := dhcp4.NewPacket(dhcp4.BootRequest)
 	packet.SetCHAddr(acknowledgement.CHAddr())
@@ -348,69 +808,282 @@ func (c *Client) DeclinePacket(acknowledgement *dhcp4.Packet) dhcp4.Packet {
 	packet.AddOption(dhcp4.OptionDHCPMessageType, []byte{byte(dhcp4.Decline)})
 	packet.AddOption(dhcp4.OptionRequestedIPAddress, (acknowledgement.YIAddr()).To4())
 	packet.AddOption(dhcp4.OptionServerIdentifier, acknowledgementOptions[dhcp4.OptionServerIdentifier])
+	c.addClientID(&packet)
 
 	return packet
 }
 
 //Lets do a Full DHCP Request.
+//A NAK is returned as a *NAKError and no Offer as ErrNoOffer.
 func (c *Client) Request() (bool, dhcp4.Packet, error) {
-	discoveryPacket, err := c.SendDiscoverPacket()
-	if err != nil {
-		return false, discoveryPacket, err
-	}
+	return c.RequestContext(context.Background())
+}
 
-	offerPacket, err := c.GetOffer(&discoveryPacket)
-	if err != nil {
-		return false, offerPacket, err
-	}
+//Request, giving up as soon as the context is done.
+func (c *Client) RequestContext(ctx context.Context) (bool, dhcp4.Packet, error) {
+	for conflicts := 1; ; conflicts++ {
+		offerPacket, err := c.selecting(ctx)
+		if err != nil {
+			return false, offerPacket, err
+		}
 
-	requestPacket, err := c.SendRequest(&offerPacket)
-	if err != nil {
-		return false, requestPacket, err
+		//With Rapid Commit the Discover may already have been Acknowledged.
+		acknowledgement := offerPacket
+		if !isACK(offerPacket) {
+			acknowledgement, err = c.requesting(ctx, &offerPacket)
+			if err != nil {
+				return false, acknowledgement, err
+			}
+		}
+
+		if !isACK(acknowledgement) {
+			return false, acknowledgement, newNAKError(acknowledgement)
+		}
+
+		declined, err := c.declineConflict(ctx, &acknowledgement)
+		if err != nil {
+			return false, acknowledgement, err
+		}
+		if !declined {
+			return true, acknowledgement, nil
+		}
+		if conflicts >= maxConflicts {
+			return false, acknowledgement, &ConflictError{Address: acknowledgement.YIAddr()}
+		}
+
+		if !sleepUntil(ctx, time.Now().Add(c.declineBackoff)) {
+			return false, acknowledgement, ctx.Err()
+		}
 	}
+}
 
-	acknowledgement, err := c.GetAcknowledgement(&requestPacket)
-	if err != nil {
-		return false, acknowledgement, err
+//Discover and wait for an Offer (SELECTING).
+func (c *Client) selecting(ctx context.Context) (dhcp4.Packet, error) {
+	discoveryPacket := c.DiscoverPacket()
+	discoveryPacket.PadToMinSize()
+
+	wait := c.getOffer
+	if c.offerSelector != nil {
+		wait = c.getSelectedOffer
 	}
 
-	acknowledgementOptions := acknowledgement.ParseOptions()
-	if dhcp4.MessageType(acknowledgementOptions[dhcp4.OptionDHCPMessageType][0]) != dhcp4.ACK {
-		return false, acknowledgement, nil
+	offerPacket, err := c.exchange(ctx, discoveryPacket, wait)
+	if err != nil && isTimeout(err) && ctx.Err() == nil {
+		return offerPacket, fmt.Errorf("%w: %w", ErrNoOffer, err)
 	}
+	return offerPacket, err
+}
 
-	return true, acknowledgement, nil
+//Request the Offer and wait for the Acknowledgement (REQUESTING).
+func (c *Client) requesting(ctx context.Context, offerPacket *dhcp4.Packet) (dhcp4.Packet, error) {
+	requestPacket := c.RequestPacket(offerPacket)
+	requestPacket.PadToMinSize()
+
+	return c.exchange(ctx, requestPacket, c.getAcknowledgement)
 }
 
 //Renew a lease backed on the Acknowledgement Packet.
+//The Request is unicast to the server if the connection is a UnicastWriter.
+//A NAK is returned as a *NAKError.
 //Returns Sucessfull, The AcknoledgementPacket, Any Errors
 func (c *Client) Renew(acknowledgement dhcp4.Packet) (bool, dhcp4.Packet, error) {
+	return c.RenewContext(context.Background(), acknowledgement)
+}
+
+//Renew, giving up as soon as the context is done.
+func (c *Client) RenewContext(ctx context.Context, acknowledgement dhcp4.Packet) (bool, dhcp4.Packet, error) {
 	renewRequest := c.RenewalRequestPacket(&acknowledgement)
 	renewRequest.PadToMinSize()
 
-	err := c.SendPacket(renewRequest)
+	//https://tools.ietf.org/html/rfc2131#section-4.4.5 RENEWING is unicast to
+	//the server which granted the lease.
+	server := net.IP(ParseReplyOptions(acknowledgement)[dhcp4.OptionServerIdentifier])
+	newAcknowledgement, err := c.exchangeTo(ctx, renewRequest, server, c.getAcknowledgement)
 	if err != nil {
-		return false, renewRequest, err
+		return false, newAcknowledgement, err
 	}
 
-	newAcknowledgement, err := c.GetAcknowledgement(&renewRequest)
+	if !isACK(newAcknowledgement) {
+		return false, newAcknowledgement, newNAKError(newAcknowledgement)
+	}
+
+	return true, newAcknowledgement, nil
+}
+
+//Rebind a Lease when the server which granted it hasn't answered our Renew.
+//The Request is broadcast (to 255.255.255.255 if the connection is a
+//UnicastWriter) without a Server Identifier so any server can extend the Lease.
+//Returns Sucessfull, The Rebound Lease, Any Errors
+func (c *Client) Rebind(lease Lease) (bool, Lease, error) {
+	return c.RebindContext(context.Background(), lease)
+}
+
+//Rebind, giving up as soon as the context is done.
+func (c *Client) RebindContext(ctx context.Context, lease Lease) (bool, Lease, error) {
+	start := time.Now()
+
+	rebindRequest := c.RebindRequestPacket(lease)
+	rebindRequest.PadToMinSize()
+
+	//https://tools.ietf.org/html/rfc2131#section-4.4.5 REBINDING is broadcast
+	//even if the connection's remote address is the server.
+	acknowledgement, err := c.exchangeTo(ctx, rebindRequest, net.IPv4bcast, c.getAcknowledgement)
 	if err != nil {
-		return false, newAcknowledgement, err
+		return false, Lease{}, err
 	}
 
-	newAcknowledgementOptions := newAcknowledgement.ParseOptions()
-	if dhcp4.MessageType(newAcknowledgementOptions[dhcp4.OptionDHCPMessageType][0]) != dhcp4.ACK {
-		return false, newAcknowledgement, nil
+	if !isACK(acknowledgement) {
+		return false, Lease{}, newNAKError(acknowledgement)
 	}
 
-	return true, newAcknowledgement, nil
+	rebound, err := NewLease(acknowledgement, start)
+	if err != nil {
+		return false, Lease{}, err
+	}
+
+	return true, rebound, nil
+}
+
+//Reclaim a previously held Lease after a reboot or link change (INIT-REBOOT).
+//A NAK returns a *NAKError and the client should start again with a full Request,
+//if no server answers the (unexpired) Lease can continue to be used and is
+//returned as is.
+//Returns Sucessfull, The Lease, Any Errors
+func (c *Client) InitReboot(lease Lease) (bool, Lease, error) {
+	return c.InitRebootContext(context.Background(), lease)
+}
+
+//InitReboot, giving up as soon as the context is done.
+//Unlike a timeout the context being done doesn't return the Lease.
+func (c *Client) InitRebootContext(ctx context.Context, lease Lease) (bool, Lease, error) {
+	start := time.Now()
+
+	rebootRequest := c.InitRebootPacket(lease)
+	rebootRequest.PadToMinSize()
+
+	acknowledgement, err := c.exchange(ctx, rebootRequest, c.getAcknowledgement)
+	if err != nil {
+		if isTimeout(err) && ctx.Err() == nil && time.Now().Before(lease.ExpiresAt()) {
+			return true, lease, nil
+		}
+		return false, Lease{}, err
+	}
+
+	if !isACK(acknowledgement) {
+		return false, Lease{}, newNAKError(acknowledgement)
+	}
+
+	rebooted, err := NewLease(acknowledgement, start)
+	if err != nil {
+		return false, Lease{}, err
+	}
+
+	return true, rebooted, nil
+}
+
+//Get the network configuration for a statically configured address (DHCPINFORM).
+//Like Request and Renew it's bounded by the Timeout (or Retransmission), use
+//InformContext to cancel it.
+//Returns The Configuration, Any Errors
+func (c *Client) Inform(ip net.IP) (Configuration, error) {
+	return c.InformContext(context.Background(), ip)
+}
+
+//Inform, giving up as soon as the context is done.
+func (c *Client) InformContext(ctx context.Context, ip net.IP) (Configuration, error) {
+	informPacket := c.InformPacket(ip)
+	informPacket.PadToMinSize()
+
+	acknowledgement, err := c.exchange(ctx, informPacket, c.getAcknowledgement)
+	if err != nil {
+		return Configuration{}, err
+	}
+
+	if !isACK(acknowledgement) {
+		return Configuration{}, newNAKError(acknowledgement)
+	}
+
+	//https://tools.ietf.org/html/rfc2131#section-4.3.5 the server doesn't
+	//allocate an address in reply to an INFORM.
+	if !acknowledgement.YIAddr().Equal(net.IPv4zero) {
+		return Configuration{}, malformed(fmt.Errorf("DHCPINFORM acknowledgement has yiaddr %v", acknowledgement.YIAddr()))
+	}
+
+	return decodeConfiguration(ParseReplyOptions(acknowledgement)), nil
 }
 
 //Release a lease backed on the Acknowledgement Packet.
+//The Release is unicast to the server if the connection is a UnicastWriter.
 //Returns Any Errors
 func (c *Client) Release(acknowledgement dhcp4.Packet) error {
 	release := c.ReleasePacket(&acknowledgement)
 	release.PadToMinSize()
 
-	return c.SendPacket(release)
+	server := net.IP(ParseReplyOptions(acknowledgement)[dhcp4.OptionServerIdentifier])
+	return c.SendPacketTo(release, server)
+}
+
+//Do a Full DHCP Request decoding the Lease from the Acknowledgement.
+//Returns Sucessfull, The Lease, Any Errors
+func (c *Client) RequestLease() (bool, Lease, error) {
+	return c.RequestLeaseContext(context.Background())
+}
+
+//RequestLease, giving up as soon as the context is done.
+func (c *Client) RequestLeaseContext(ctx context.Context) (bool, Lease, error) {
+	start := time.Now()
+
+	success, acknowledgement, err := c.RequestContext(ctx)
+	if err != nil || !success {
+		return false, Lease{}, err
+	}
+
+	lease, err := NewLease(acknowledgement, start)
+	if err != nil {
+		return false, Lease{}, err
+	}
+
+	return true, lease, nil
+}
+
+//Renew a Lease.
+//Returns Sucessfull, The Renewed Lease, Any Errors
+func (c *Client) RenewLease(lease Lease) (bool, Lease, error) {
+	return c.RenewLeaseContext(context.Background(), lease)
+}
+
+//RenewLease, giving up as soon as the context is done.
+func (c *Client) RenewLeaseContext(ctx context.Context, lease Lease) (bool, Lease, error) {
+	start := time.Now()
+
+	success, acknowledgement, err := c.RenewContext(ctx, lease.Acknowledgement())
+	if err != nil || !success {
+		return false, Lease{}, err
+	}
+
+	renewed, err := NewLease(acknowledgement, start)
+	if err != nil {
+		return false, Lease{}, err
+	}
+
+	return true, renewed, nil
+}
+
+//Release a Lease.
+//Returns Any Errors
+func (c *Client) ReleaseLease(lease Lease) error {
+	return c.Release(lease.Acknowledgement())
+}
+
+//The options in the Parameter Request List the server didn't send.
+func MissingOptions(acknowledgement dhcp4.Packet, requested []dhcp4.OptionCode) []dhcp4.OptionCode {
+	options := ParseReplyOptions(acknowledgement)
+
+	var missing []dhcp4.OptionCode
+	for _, code := range requested {
+		if _, ok := options[code]; !ok {
+			missing = append(missing, code)
+		}
+	}
+	return missing
 }
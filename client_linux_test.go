@@ -1,10 +1,12 @@
 package dhcp4client_test
 
 import (
+	"errors"
 	"log"
 	"net"
 	"testing"
 	"syscall"
+	"time"
 
 	"github.com/d2g/dhcp4"
 	"github.com/d2g/dhcp4client"
@@ -75,8 +77,7 @@ func Test_ExampleLinuxClient(test *testing.T) {
 	test.Logf("Packet:%v\n", acknowledgementpacket)
 
 	if err != nil {
-		networkError, ok := err.(net.Error)
-		if ok && networkError.Timeout() {
+		if errors.Is(err, dhcp4client.ErrNoOffer) {
 			test.Log("Test Skipping as it didn't find a DHCP Server")
 			test.SkipNow()
 		}
@@ -90,3 +91,57 @@ func Test_ExampleLinuxClient(test *testing.T) {
 	}
 
 }
+
+//packetSock reads every IPv4 packet on the interface, one too large to read
+//is skipped rather than ending the wait for the Offer.
+func Test_PacketSockSkipsTruncated(test *testing.T) {
+	lo, err := net.InterfaceByName("lo")
+	if err != nil {
+		test.Skipf("No loopback interface:%v", err)
+	}
+
+	c, err := dhcp4client.NewPacketSock(lo.Index)
+	if err != nil {
+		if errors.Is(err, syscall.EPERM) {
+			test.Skip("Test Skipping as it needs CAP_NET_RAW")
+		}
+		test.Fatalf("Client Connection Generation:%v\n", err)
+	}
+	defer c.Close()
+
+	exampleClient, err := dhcp4client.New(dhcp4client.HardwareAddr(net.HardwareAddr{0x08, 0x00, 0x27, 0x00, 0xA8, 0xE8}), dhcp4client.Connection(c), dhcp4client.Timeout(time.Second*2))
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	defer exampleClient.Close()
+
+	receiver, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	defer receiver.Close()
+
+	sender, err := net.DialUDP("udp4", nil, receiver.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	defer sender.Close()
+
+	discoveryPacket := exampleClient.DiscoverPacket()
+	offerPacket := dhcp4.ReplyPacket(discoveryPacket, dhcp4.Offer, net.IPv4(192, 168, 1, 1), net.IPv4(192, 168, 1, 100), time.Hour, nil)
+
+	go func() {
+		//Give GetOffer time to start reading.
+		time.Sleep(time.Millisecond * 100)
+		sender.Write(make([]byte, 1500))
+		sender.Write(offerPacket)
+	}()
+
+	offer, err := exampleClient.GetOffer(&discoveryPacket)
+	if err != nil {
+		test.Fatalf("Offer Error:%v\n", err)
+	}
+	if !offer.YIAddr().Equal(net.IPv4(192, 168, 1, 100)) {
+		test.Errorf("Offered %v, expected %v", offer.YIAddr(), net.IPv4(192, 168, 1, 100))
+	}
+}
@@ -1,10 +1,13 @@
 package dhcp4client_test
 
 import (
+	"errors"
 	"log"
 	"net"
 	"testing"
+	"time"
 
+	"github.com/d2g/dhcp4"
 	"github.com/d2g/dhcp4client"
 )
 
@@ -37,8 +40,7 @@ func Test_ExampleClient(test *testing.T) {
 	test.Logf("Packet:%v\n", acknowledgementpacket)
 
 	if err != nil {
-		networkError, ok := err.(*net.OpError)
-		if ok && networkError.Timeout() {
+		if errors.Is(err, dhcp4client.ErrNoOffer) {
 			test.Log("Test Skipping as it didn't find a DHCP Server")
 			test.SkipNow()
 		}
@@ -54,8 +56,8 @@ func Test_ExampleClient(test *testing.T) {
 	test.Log("Start Renewing Lease")
 	success, acknowledgementpacket, err = exampleClient.Renew(acknowledgementpacket)
 	if err != nil {
-		networkError, ok := err.(*net.OpError)
-		if ok && networkError.Timeout() {
+		var timeoutError *dhcp4client.TimeoutError
+		if errors.As(err, &timeoutError) {
 			test.Log("Renewal Failed! Because it didn't find the DHCP server very Strange")
 			test.Errorf("Error" + err.Error())
 		}
@@ -100,8 +102,7 @@ func Test_ExampleClientWithMathGenerateXID(test *testing.T) {
 	test.Logf("Packet:%v\n", acknowledgementpacket)
 
 	if err != nil {
-		networkError, ok := err.(*net.OpError)
-		if ok && networkError.Timeout() {
+		if errors.Is(err, dhcp4client.ErrNoOffer) {
 			test.Log("Test Skipping as it didn't find a DHCP Server")
 			test.SkipNow()
 		}
@@ -114,3 +115,160 @@ func Test_ExampleClientWithMathGenerateXID(test *testing.T) {
 		log.Printf("IP Received:%v\n", acknowledgementpacket.YIAddr().String())
 	}
 }
+
+func newTestClient(test *testing.T, server *testServer) *dhcp4client.Client {
+	m, err := net.ParseMAC("08-00-27-00-A8-E8")
+	if err != nil {
+		test.Fatalf("MAC Error:%v\n", err)
+	}
+
+	c, err := dhcp4client.New(dhcp4client.HardwareAddr(m), dhcp4client.Connection(server), dhcp4client.Timeout(time.Millisecond*200))
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	return c
+}
+
+func Test_InitReboot(test *testing.T) {
+	server := newTestServer()
+	c := newTestClient(test, server)
+
+	success, lease, err := c.RequestLease()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	success, rebooted, err := c.InitReboot(lease)
+	if err != nil || !success {
+		test.Fatalf("InitReboot Success:%v Error:%v\n", success, err)
+	}
+
+	if !rebooted.FixedAddress.Equal(lease.FixedAddress) {
+		test.Errorf("Rebooted with %v, expected %v", rebooted.FixedAddress, lease.FixedAddress)
+	}
+
+	sent := server.SentPackets()
+	rebootRequest := sent[len(sent)-1]
+	options := rebootRequest.ParseOptions()
+
+	if !net.IP(options[dhcp4.OptionRequestedIPAddress]).Equal(lease.FixedAddress) {
+		test.Errorf("Requested IP Address:%v, expected %v", net.IP(options[dhcp4.OptionRequestedIPAddress]), lease.FixedAddress)
+	}
+	if _, ok := options[dhcp4.OptionServerIdentifier]; ok {
+		test.Error("INIT-REBOOT Request must not contain a Server Identifier")
+	}
+	if !rebootRequest.CIAddr().Equal(net.IPv4zero) {
+		test.Errorf("INIT-REBOOT Request ciaddr:%v", rebootRequest.CIAddr())
+	}
+}
+
+func Test_InitRebootNAK(test *testing.T) {
+	server := newTestServer()
+	c := newTestClient(test, server)
+
+	success, lease, err := c.RequestLease()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	server.Reply = nak
+	success, _, err = c.InitReboot(lease)
+	var nakError *dhcp4client.NAKError
+	if !errors.As(err, &nakError) {
+		test.Fatalf("Error:%v, expected a *NAKError\n", err)
+	}
+	if success {
+		test.Error("InitReboot succeeded despite a NAK")
+	}
+}
+
+func Test_InitRebootNoResponse(test *testing.T) {
+	server := newTestServer()
+	c := newTestClient(test, server)
+
+	success, lease, err := c.RequestLease()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	server.Reply = func(dhcp4.Packet, dhcp4.Packet) []dhcp4.Packet { return nil }
+	success, kept, err := c.InitReboot(lease)
+	if err != nil || !success {
+		test.Fatalf("InitReboot Success:%v Error:%v\n", success, err)
+	}
+	if !kept.Acquired.Equal(lease.Acquired) {
+		test.Error("InitReboot without a response should keep the existing lease")
+	}
+}
+
+func Test_Rebind(test *testing.T) {
+	server := newTestServer()
+	c := newTestClient(test, server)
+
+	success, lease, err := c.RequestLease()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	success, rebound, err := c.Rebind(lease)
+	if err != nil || !success {
+		test.Fatalf("Rebind Success:%v Error:%v\n", success, err)
+	}
+
+	if !rebound.FixedAddress.Equal(lease.FixedAddress) {
+		test.Errorf("Rebound with %v, expected %v", rebound.FixedAddress, lease.FixedAddress)
+	}
+
+	sent := server.SentPackets()
+	rebindRequest := sent[len(sent)-1]
+
+	if _, ok := rebindRequest.ParseOptions()[dhcp4.OptionServerIdentifier]; ok {
+		test.Error("Rebind Request must not contain a Server Identifier")
+	}
+	if !rebindRequest.CIAddr().Equal(lease.FixedAddress) {
+		test.Errorf("Rebind Request ciaddr:%v, expected %v", rebindRequest.CIAddr(), lease.FixedAddress)
+	}
+}
+
+func Test_Inform(test *testing.T) {
+	server := newTestServer()
+	server.Options = []dhcp4.Option{
+		{Code: dhcp4.OptionDomainNameServer, Value: []byte{192, 168, 1, 53}},
+		{Code: dhcp4.OptionNetworkTimeProtocolServers, Value: []byte{192, 168, 1, 123}},
+		{Code: dhcp4.OptionDomainName, Value: []byte("example.com")},
+	}
+	c := newTestClient(test, server)
+
+	configuration, err := c.Inform(net.IPv4(192, 168, 1, 10))
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	if len(configuration.DNSServers) != 1 || !configuration.DNSServers[0].Equal(net.IPv4(192, 168, 1, 53)) {
+		test.Errorf("DNSServers:%v", configuration.DNSServers)
+	}
+	if len(configuration.NTPServers) != 1 || !configuration.NTPServers[0].Equal(net.IPv4(192, 168, 1, 123)) {
+		test.Errorf("NTPServers:%v", configuration.NTPServers)
+	}
+	if configuration.DomainName != "example.com" {
+		test.Errorf("DomainName:%v", configuration.DomainName)
+	}
+
+	informPacket := server.SentPackets()[0]
+	if !informPacket.CIAddr().Equal(net.IPv4(192, 168, 1, 10)) {
+		test.Errorf("Inform ciaddr:%v", informPacket.CIAddr())
+	}
+}
+
+func Test_InformRejectsAllocation(test *testing.T) {
+	server := newTestServer()
+	server.Reply = func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+		reply.SetYIAddr(net.IPv4(192, 168, 1, 100))
+		return []dhcp4.Packet{reply}
+	}
+	c := newTestClient(test, server)
+
+	if _, err := c.Inform(net.IPv4(192, 168, 1, 10)); err == nil {
+		test.Error("Expected an error for an INFORM acknowledgement with a yiaddr")
+	}
+}
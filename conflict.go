@@ -0,0 +1,59 @@
+package dhcp4client
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/d2g/dhcp4"
+)
+
+const (
+	//https://tools.ietf.org/html/rfc5227#section-1.1 MAX_CONFLICTS, after
+	//which we give up rather than keep Declining.
+	maxConflicts = 10
+)
+
+//Checks whether an address is already in use on the network before we
+//accept a Lease for it (e.g. RFC 5227 ARP Probing).
+type ConflictDetector interface {
+	//Returns true if another host is using the address.
+	Probe(ip net.IP) (bool, error)
+}
+
+//Implemented by ConflictDetectors which can stop Probing as soon as the
+//context is done, otherwise the Context methods wait for Probe to finish.
+type ContextConflictDetector interface {
+	ProbeContext(ctx context.Context, ip net.IP) (bool, error)
+}
+
+//ConflictError records that every address offered was already in use.
+type ConflictError struct {
+	Address net.IP
+}
+
+func (ce *ConflictError) Error() string {
+	return fmt.Sprintf("address %v is already in use", ce.Address)
+}
+
+//Probe the address in the Acknowledgement and Decline it if it's in use.
+//Returns true if the address was declined.
+func (c *Client) declineConflict(ctx context.Context, acknowledgement *dhcp4.Packet) (bool, error) {
+	if c.conflict == nil {
+		return false, nil
+	}
+
+	var conflict bool
+	var err error
+	if detector, ok := c.conflict.(ContextConflictDetector); ok {
+		conflict, err = detector.ProbeContext(ctx, acknowledgement.YIAddr())
+	} else {
+		conflict, err = c.conflict.Probe(acknowledgement.YIAddr())
+	}
+	if err != nil || !conflict {
+		return false, err
+	}
+
+	_, err = c.SendDecline(acknowledgement)
+	return true, err
+}
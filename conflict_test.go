@@ -0,0 +1,108 @@
+package dhcp4client_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+//Reports every address in inUse as a conflict.
+type testConflictDetector struct {
+	inUse  []net.IP
+	probed []net.IP
+}
+
+func (d *testConflictDetector) Probe(ip net.IP) (bool, error) {
+	d.probed = append(d.probed, ip)
+	for _, used := range d.inUse {
+		if used.Equal(ip) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func Test_RequestDeclinesConflict(test *testing.T) {
+	server := newTestServer()
+	server.Reply = func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+		//Hand out the next address once the first has been Acknowledged.
+		if dhcp4.MessageType(request.ParseOptions()[dhcp4.OptionDHCPMessageType][0]) == dhcp4.Request {
+			server.ClientIP = net.IPv4(192, 168, 1, 101)
+		}
+		return []dhcp4.Packet{reply}
+	}
+
+	detector := &testConflictDetector{inUse: []net.IP{net.IPv4(192, 168, 1, 100)}}
+
+	c := newTestClient(test, server)
+	c.SetOption(dhcp4client.ConflictDetection(detector), dhcp4client.DeclineBackoff(time.Millisecond*10))
+
+	success, acknowledgement, err := c.Request()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	if !acknowledgement.YIAddr().Equal(net.IPv4(192, 168, 1, 101)) {
+		test.Errorf("Accepted %v, expected %v", acknowledgement.YIAddr(), net.IPv4(192, 168, 1, 101))
+	}
+
+	if len(detector.probed) != 2 {
+		test.Errorf("Probed %v, expected both addresses", detector.probed)
+	}
+
+	declined := false
+	for _, sent := range server.SentPackets() {
+		options := sent.ParseOptions()
+		if dhcp4.MessageType(options[dhcp4.OptionDHCPMessageType][0]) == dhcp4.Decline {
+			declined = net.IP(options[dhcp4.OptionRequestedIPAddress]).Equal(net.IPv4(192, 168, 1, 100))
+		}
+	}
+	if !declined {
+		test.Error("Conflicting address wasn't Declined")
+	}
+}
+
+func Test_RequestGivesUpOnConflicts(test *testing.T) {
+	server := newTestServer()
+	detector := &testConflictDetector{inUse: []net.IP{server.ClientIP}}
+
+	c := newTestClient(test, server)
+	c.SetOption(dhcp4client.ConflictDetection(detector), dhcp4client.DeclineBackoff(time.Millisecond))
+
+	_, _, err := c.Request()
+	if _, ok := err.(*dhcp4client.ConflictError); !ok {
+		test.Errorf("Error:%v, expected a *ConflictError", err)
+	}
+}
+
+//Probes until the context is done.
+type blockingConflictDetector struct{}
+
+func (blockingConflictDetector) Probe(ip net.IP) (bool, error) {
+	return false, errors.New("Probe called instead of ProbeContext")
+}
+
+func (blockingConflictDetector) ProbeContext(ctx context.Context, ip net.IP) (bool, error) {
+	<-ctx.Done()
+	return false, ctx.Err()
+}
+
+func Test_RequestContextCancelsProbe(test *testing.T) {
+	server := newTestServer()
+
+	c := newTestClient(test, server)
+	c.SetOption(dhcp4client.ConflictDetection(blockingConflictDetector{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+
+	_, _, err := c.RequestContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		test.Errorf("Error:%v, expected %v", err, context.DeadlineExceeded)
+	}
+}
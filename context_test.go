@@ -0,0 +1,96 @@
+package dhcp4client_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+func Test_RequestContextCancel(test *testing.T) {
+	server := newTestServer()
+	server.Reply = func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+		return nil
+	}
+
+	c := newTestClient(test, server)
+	if err := c.SetOption(dhcp4client.Timeout(time.Second * 10)); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*50, cancel)
+
+	start := time.Now()
+	success, _, err := c.RequestContext(ctx)
+	if success || err != context.Canceled {
+		test.Errorf("Request Success:%v Error:%v, expected %v", success, err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		test.Errorf("Request took %v to return after being cancelled", elapsed)
+	}
+}
+
+func Test_InetSockContext(test *testing.T) {
+	c, err := dhcp4client.NewInetSock(dhcp4client.SetLocalAddr(net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}), dhcp4client.SetRemoteAddr(net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9}))
+	if err != nil {
+		test.Fatalf("Client Connection Generation:%v\n", err)
+	}
+
+	exampleClient, err := dhcp4client.New(dhcp4client.Connection(c), dhcp4client.Timeout(time.Second*10))
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	defer exampleClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	start := time.Now()
+	discoveryPacket := exampleClient.DiscoverPacket()
+	_, err = exampleClient.GetOfferContext(ctx, &discoveryPacket)
+	if err != context.DeadlineExceeded {
+		test.Errorf("Error:%v, expected %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		test.Errorf("GetOffer took %v to return after the deadline", elapsed)
+	}
+}
+
+func Test_LeaseManagerShutdown(test *testing.T) {
+	server := newTestServer()
+	server.Reply = func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+		return nil
+	}
+
+	c := newTestClient(test, server)
+	if err := c.SetOption(dhcp4client.Timeout(time.Second * 10)); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	manager, err := dhcp4client.NewLeaseManager(c)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- manager.Run(ctx)
+	}()
+
+	time.Sleep(time.Millisecond * 50)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			test.Errorf("Error:%v, expected %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		test.Fatalf("Run didn't return after being cancelled, State:%v", manager.State())
+	}
+}
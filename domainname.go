@@ -0,0 +1,133 @@
+package dhcp4client
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+const (
+	maxLabelLen      = 63
+	maxDomainNameLen = 255
+)
+
+//Encode a domain name in DNS wire format (RFC 1035 section 3.1).
+//A fully qualified name (ending in a ".") is terminated with the root label,
+//a partial name isn't.
+func encodeDomainName(name string) ([]byte, error) {
+	if name == "" || name == "." {
+		return []byte{0}, nil
+	}
+
+	fullyQualified := strings.HasSuffix(name, ".")
+	labels := strings.Split(strings.TrimSuffix(name, "."), ".")
+
+	var b []byte
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > maxLabelLen {
+			return nil, fmt.Errorf("invalid label %q in domain name %q", label, name)
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+
+	if fullyQualified {
+		b = append(b, 0)
+	}
+
+	if len(b) > maxDomainNameLen {
+		return nil, fmt.Errorf("domain name %q is longer than %d bytes", name, maxDomainNameLen)
+	}
+	return b, nil
+}
+
+//Decode a (possibly partial) domain name in DNS wire format.
+//Fully qualified names are returned with a trailing ".".
+func decodeDomainName(b []byte) (string, error) {
+	var labels []string
+
+	for len(b) > 0 {
+		length := int(b[0])
+		if length == 0 {
+			return strings.Join(labels, ".") + ".", nil
+		}
+		if length > maxLabelLen || len(b) < 1+length {
+			return "", fmt.Errorf("invalid label length %d", length)
+		}
+		labels = append(labels, string(b[1:1+length]))
+		b = b[1+length:]
+	}
+
+	return strings.Join(labels, "."), nil
+}
+
+//Decode a Domain Search list (option 119, RFC 3397): fully qualified domain
+//names in DNS wire format, compressed with pointers relative to the start of
+//the option (RFC 1035 section 4.1.4). Names are returned without a trailing
+//".".
+func ParseDomainSearch(b []byte) ([]string, error) {
+	var names []string
+
+	for offset := 0; offset < len(b); {
+		name, next, err := decodeCompressedName(b, offset)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		offset = next
+	}
+
+	return names, nil
+}
+
+//Decode the compressed name at offset in b, returning it and the offset after
+//it.
+func decodeCompressedName(b []byte, offset int) (string, int, error) {
+	var labels []string
+	length := 0
+	start := offset
+	next := -1
+
+	for {
+		if offset >= len(b) {
+			return "", 0, fmt.Errorf("domain name at %d is truncated", start)
+		}
+
+		switch l := int(b[offset]); {
+		case l == 0:
+			if next < 0 {
+				next = offset + 1
+			}
+			return strings.Join(labels, "."), next, nil
+
+		case l&0xC0 == 0xC0:
+			if offset+1 >= len(b) {
+				return "", 0, fmt.Errorf("domain name at %d is truncated", start)
+			}
+			//Each pointer must point before the last, which stops loops.
+			pointer := int(binary.BigEndian.Uint16(b[offset:]) & 0x3FFF)
+			if pointer >= start {
+				return "", 0, fmt.Errorf("invalid compression pointer %d at %d", pointer, offset)
+			}
+			if next < 0 {
+				next = offset + 2
+			}
+			offset = pointer
+			start = pointer
+
+		case l > maxLabelLen:
+			return "", 0, fmt.Errorf("invalid label length %d", l)
+
+		default:
+			if offset+1+l > len(b) {
+				return "", 0, fmt.Errorf("domain name at %d is truncated", start)
+			}
+			length += 1 + l
+			if length > maxDomainNameLen {
+				return "", 0, fmt.Errorf("domain name is longer than %d bytes", maxDomainNameLen)
+			}
+			labels = append(labels, string(b[offset+1:offset+1+l]))
+			offset += 1 + l
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package dhcp4client
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+)
+
+//DUID types https://tools.ietf.org/html/rfc8415#section-11
+const (
+	duidLLT  = 1
+	duidEN   = 2
+	duidUUID = 4 //RFC 6355
+)
+
+//https://tools.ietf.org/html/rfc4361#section-6.1 the Client Identifier type
+//for an IAID followed by a DUID.
+const nodeSpecificClientIDType = 255
+
+var (
+	//DUID-LLT times are seconds since midnight (UTC), January 1, 2000.
+	duidEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+//Build an RFC 4361 node-specific Client Identifier (type 255, IAID, DUID)
+//so the DHCPv4 client identifies itself in the same way as the DHCPv6 client.
+func NodeSpecificClientID(iaid uint32, duid []byte) []byte {
+	id := make([]byte, 5, 5+len(duid))
+	id[0] = nodeSpecificClientIDType
+	binary.BigEndian.PutUint32(id[1:5], iaid)
+	return append(id, duid...)
+}
+
+//Build a DUID based on Link-layer Address plus Time (DUID-LLT).
+//hardwareType is the IANA hardware type, 1 for Ethernet.
+func DUIDLLT(hardwareType uint16, t time.Time, hardwareAddr net.HardwareAddr) []byte {
+	duid := make([]byte, 8, 8+len(hardwareAddr))
+	binary.BigEndian.PutUint16(duid[0:2], duidLLT)
+	binary.BigEndian.PutUint16(duid[2:4], hardwareType)
+	binary.BigEndian.PutUint32(duid[4:8], uint32(t.Sub(duidEpoch)/time.Second))
+	return append(duid, hardwareAddr...)
+}
+
+//Build a DUID Assigned by Vendor Based on Enterprise Number (DUID-EN).
+func DUIDEN(enterpriseNumber uint32, identifier []byte) []byte {
+	duid := make([]byte, 6, 6+len(identifier))
+	binary.BigEndian.PutUint16(duid[0:2], duidEN)
+	binary.BigEndian.PutUint32(duid[2:6], enterpriseNumber)
+	return append(duid, identifier...)
+}
+
+//Build a DUID based on a Universally Unique Identifier (DUID-UUID, RFC 6355).
+func DUIDUUID(uuid [16]byte) []byte {
+	duid := make([]byte, 2, 2+len(uuid))
+	binary.BigEndian.PutUint16(duid[0:2], duidUUID)
+	return append(duid, uuid[:]...)
+}
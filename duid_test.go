@@ -0,0 +1,72 @@
+package dhcp4client_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+func Test_DUIDs(test *testing.T) {
+	m, err := net.ParseMAC("08-00-27-00-A8-E8")
+	if err != nil {
+		test.Fatalf("MAC Error:%v\n", err)
+	}
+
+	duids := []struct {
+		name     string
+		duid     []byte
+		expected []byte
+	}{
+		{"DUID-LLT", dhcp4client.DUIDLLT(1, time.Date(2000, time.January, 1, 0, 1, 0, 0, time.UTC), m), []byte{0, 1, 0, 1, 0, 0, 0, 60, 8, 0, 39, 0, 168, 232}},
+		{"DUID-EN", dhcp4client.DUIDEN(9, []byte{1, 2, 3}), []byte{0, 2, 0, 0, 0, 9, 1, 2, 3}},
+		{"DUID-UUID", dhcp4client.DUIDUUID([16]byte{15: 1}), []byte{0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}},
+	}
+
+	for _, d := range duids {
+		if !bytes.Equal(d.duid, d.expected) {
+			test.Errorf("%s:%v, expected %v", d.name, d.duid, d.expected)
+		}
+	}
+
+	id := dhcp4client.NodeSpecificClientID(2, []byte{0, 2, 0, 0, 0, 9, 1, 2, 3})
+	if expected := []byte{255, 0, 0, 0, 2, 0, 2, 0, 0, 0, 9, 1, 2, 3}; !bytes.Equal(id, expected) {
+		test.Errorf("Client ID:%v, expected %v", id, expected)
+	}
+}
+
+func Test_ClientIDInEveryPacket(test *testing.T) {
+	id := dhcp4client.NodeSpecificClientID(1, dhcp4client.DUIDEN(9, []byte{1, 2, 3}))
+
+	server := newTestServer()
+	c := newTestClient(test, server)
+	c.SetOption(dhcp4client.ClientID(id))
+
+	success, lease, err := c.RequestLease()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	if _, _, err := c.RenewLease(lease); err != nil {
+		test.Fatalf("Renew Error:%v\n", err)
+	}
+
+	acknowledgement := lease.Acknowledgement()
+	if _, err := c.SendDecline(&acknowledgement); err != nil {
+		test.Fatalf("Decline Error:%v\n", err)
+	}
+
+	if err := c.ReleaseLease(lease); err != nil {
+		test.Fatalf("Release Error:%v\n", err)
+	}
+
+	for _, sent := range server.SentPackets() {
+		options := sent.ParseOptions()
+		if !bytes.Equal(options[dhcp4.OptionClientIdentifier], id) {
+			test.Errorf("Message Type %v has Client Identifier %v, expected %v", options[dhcp4.OptionDHCPMessageType], options[dhcp4.OptionClientIdentifier], id)
+		}
+	}
+}
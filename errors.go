@@ -0,0 +1,54 @@
+package dhcp4client
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/d2g/dhcp4"
+)
+
+var (
+	//No server made an Offer before we gave up, wraps the *TimeoutError.
+	ErrNoOffer = errors.New("no DHCP offer received")
+
+	//The reply came from a different server to the one we sent the Request to.
+	ErrServerMismatch = errors.New("DHCP reply from an unexpected server")
+
+	//The reply couldn't be decoded.
+	ErrMalformedReply = errors.New("malformed DHCP reply")
+)
+
+//NAKError records a server refusing a Request with a DHCPNAK.
+type NAKError struct {
+	Message          string       //Why the server refused (option 56), if it said.
+	ServerIdentifier net.IP       //The server that refused (option 54).
+	Packet           dhcp4.Packet //The NAK.
+}
+
+func newNAKError(nak dhcp4.Packet) *NAKError {
+	options := ParseReplyOptions(nak)
+	return &NAKError{
+		Message:          string(options[dhcp4.OptionMessage]),
+		ServerIdentifier: copyIP(options[dhcp4.OptionServerIdentifier]),
+		Packet:           nak,
+	}
+}
+
+func (ne *NAKError) Error() string {
+	if ne.Message == "" {
+		return fmt.Sprintf("DHCPNAK from %v", ne.ServerIdentifier)
+	}
+	return fmt.Sprintf("DHCPNAK from %v: %s", ne.ServerIdentifier, ne.Message)
+}
+
+//Was the Request refused with a NAK.
+func isNAK(err error) bool {
+	var nakError *NAKError
+	return errors.As(err, &nakError)
+}
+
+//Wrap an error decoding a reply so it matches ErrMalformedReply.
+func malformed(err error) error {
+	return fmt.Errorf("%w: %v", ErrMalformedReply, err)
+}
@@ -0,0 +1,98 @@
+package dhcp4client_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+func Test_NAKError(test *testing.T) {
+	server := newTestServer()
+	server.Reply = func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+		if dhcp4.MessageType(request.ParseOptions()[dhcp4.OptionDHCPMessageType][0]) != dhcp4.Request {
+			return []dhcp4.Packet{reply}
+		}
+		return []dhcp4.Packet{dhcp4.ReplyPacket(request, dhcp4.NAK, server.ServerIP, nil, 0, []dhcp4.Option{
+			{Code: dhcp4.OptionMessage, Value: []byte("address not available")},
+		})}
+	}
+	c := newTestClient(test, server)
+
+	success, _, err := c.Request()
+	if success {
+		test.Error("Request succeeded despite a NAK")
+	}
+
+	var nakError *dhcp4client.NAKError
+	if !errors.As(err, &nakError) {
+		test.Fatalf("Error:%v, expected a *NAKError\n", err)
+	}
+	if nakError.Message != "address not available" {
+		test.Errorf("Message:%q", nakError.Message)
+	}
+	if !nakError.ServerIdentifier.Equal(server.ServerIP) {
+		test.Errorf("ServerIdentifier:%v", nakError.ServerIdentifier)
+	}
+}
+
+func Test_ErrNoOffer(test *testing.T) {
+	server := newTestServer()
+	server.Reply = func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+		return nil
+	}
+	c := newTestClient(test, server)
+
+	_, _, err := c.Request()
+	if !errors.Is(err, dhcp4client.ErrNoOffer) {
+		test.Errorf("Error:%v, expected %v", err, dhcp4client.ErrNoOffer)
+	}
+
+	var timeoutError *dhcp4client.TimeoutError
+	if !errors.As(err, &timeoutError) {
+		test.Errorf("Error:%v, expected a *TimeoutError", err)
+	}
+}
+
+func Test_ErrServerMismatch(test *testing.T) {
+	server := newTestServer()
+	server.Reply = func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+		if dhcp4.MessageType(request.ParseOptions()[dhcp4.OptionDHCPMessageType][0]) != dhcp4.Request {
+			return []dhcp4.Packet{reply}
+		}
+		return []dhcp4.Packet{dhcp4.ReplyPacket(request, dhcp4.ACK, net.IPv4(192, 168, 1, 2).To4(), server.ClientIP, server.LeaseTime, nil)}
+	}
+	c := newTestClient(test, server)
+
+	success, _, err := c.Request()
+	if success || !errors.Is(err, dhcp4client.ErrServerMismatch) {
+		test.Errorf("Request Success:%v Error:%v, expected %v", success, err, dhcp4client.ErrServerMismatch)
+	}
+}
+
+func Test_ErrMalformedReply(test *testing.T) {
+	acknowledgement := testAcknowledgement([]dhcp4.Option{
+		{Code: dhcp4.OptionServerIdentifier, Value: []byte{192, 168, 1}},
+	})
+
+	_, err := dhcp4client.NewLease(acknowledgement, time.Now())
+	if !errors.Is(err, dhcp4client.ErrMalformedReply) {
+		test.Errorf("Error:%v, expected %v", err, dhcp4client.ErrMalformedReply)
+	}
+
+	//An option we can do without is left out with a warning.
+	acknowledgement = testAcknowledgement([]dhcp4.Option{
+		{Code: dhcp4.OptionSubnetMask, Value: []byte{255, 255}},
+	})
+
+	lease, err := dhcp4client.NewLease(acknowledgement, time.Now())
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if len(lease.Warnings) != 1 || !errors.Is(lease.Warnings[0], dhcp4client.ErrMalformedReply) {
+		test.Errorf("Warnings:%v, expected one matching %v", lease.Warnings, dhcp4client.ErrMalformedReply)
+	}
+}
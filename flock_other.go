@@ -0,0 +1,16 @@
+//go:build !unix
+
+package dhcp4client
+
+import (
+	"os"
+)
+
+//Without flock the file can't be shared between processes.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
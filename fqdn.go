@@ -0,0 +1,67 @@
+package dhcp4client
+
+import (
+	"fmt"
+)
+
+//Client FQDN (option 81) flags https://tools.ietf.org/html/rfc4702#section-2.1
+type FQDNFlags byte
+
+const (
+	//Client: the server should perform the A update.
+	//Server: the server has (or will) perform the A update.
+	FQDNServerUpdate FQDNFlags = 0x01
+	//Server: the server has overridden the client's S flag.
+	FQDNOverride FQDNFlags = 0x02
+	//The name is in canonical wire format rather than ASCII.
+	FQDNEncoded FQDNFlags = 0x04
+	//Client: the server should perform no updates.
+	//Server: the server won't perform any updates.
+	FQDNNoUpdate FQDNFlags = 0x08
+)
+
+//A Client FQDN option (81) as defined by RFC 4702.
+type ClientFQDN struct {
+	Flags  FQDNFlags
+	RCode1 byte //Deprecated, sent as 0 and set to 255 by servers.
+	RCode2 byte //Deprecated, sent as 0 and set to 255 by servers.
+	Name   string
+}
+
+//Encode the option, the name is always in canonical wire format.
+func (f ClientFQDN) encode() ([]byte, error) {
+	if f.Flags&FQDNServerUpdate != 0 && f.Flags&FQDNNoUpdate != 0 {
+		return nil, fmt.Errorf("client FQDN flags S and N can't both be set")
+	}
+
+	name, err := encodeDomainName(f.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{byte(f.Flags | FQDNEncoded), f.RCode1, f.RCode2}, name...), nil
+}
+
+//Decode a Client FQDN option (81), usually from a server's reply.
+func ParseClientFQDN(b []byte) (ClientFQDN, error) {
+	if len(b) < 3 {
+		return ClientFQDN{}, fmt.Errorf("client FQDN option has length %d, expected at least 3", len(b))
+	}
+
+	f := ClientFQDN{
+		Flags:  FQDNFlags(b[0]),
+		RCode1: b[1],
+		RCode2: b[2],
+		Name:   string(b[3:]),
+	}
+
+	if f.Flags&FQDNEncoded != 0 {
+		name, err := decodeDomainName(b[3:])
+		if err != nil {
+			return ClientFQDN{}, err
+		}
+		f.Name = name
+	}
+
+	return f, nil
+}
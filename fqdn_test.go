@@ -0,0 +1,107 @@
+package dhcp4client_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+func Test_HostnameAndFQDN(test *testing.T) {
+	server := newTestServer()
+	server.Options = []dhcp4.Option{
+		{Code: dhcp4client.OptionClientFQDN, Value: []byte{byte(dhcp4client.FQDNServerUpdate | dhcp4client.FQDNOverride | dhcp4client.FQDNEncoded), 255, 255, 4, 'h', 'o', 's', 't', 7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0}},
+	}
+
+	c := newTestClient(test, server)
+	err := c.SetOption(dhcp4client.Hostname("host"), dhcp4client.FQDN("host.example.com.", dhcp4client.FQDNServerUpdate))
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	success, lease, err := c.RequestLease()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	expected := []byte{byte(dhcp4client.FQDNServerUpdate | dhcp4client.FQDNEncoded), 0, 0, 4, 'h', 'o', 's', 't', 7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0}
+	for _, sent := range server.SentPackets() {
+		options := sent.ParseOptions()
+		//https://tools.ietf.org/html/rfc4702#section-3.1
+		if hostname, ok := options[dhcp4.OptionHostName]; ok {
+			test.Errorf("Host Name:%q, expected none with a Client FQDN", hostname)
+		}
+		if !bytes.Equal(options[dhcp4client.OptionClientFQDN], expected) {
+			test.Errorf("Client FQDN:%v, expected %v", options[dhcp4client.OptionClientFQDN], expected)
+		}
+	}
+
+	if lease.FQDN == nil {
+		test.Fatal("Lease is missing the server's Client FQDN")
+	}
+	if lease.FQDN.Name != "host.example.com." {
+		test.Errorf("Client FQDN Name:%q", lease.FQDN.Name)
+	}
+	if lease.FQDN.Flags&dhcp4client.FQDNServerUpdate == 0 || lease.FQDN.Flags&dhcp4client.FQDNOverride == 0 {
+		test.Errorf("Client FQDN Flags:%v", lease.FQDN.Flags)
+	}
+}
+
+//Without an FQDN the Host Name is sent.
+func Test_Hostname(test *testing.T) {
+	server := newTestServer()
+
+	c := newTestClient(test, server)
+	if err := c.SetOption(dhcp4client.Hostname("host")); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	success, _, err := c.RequestLease()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	for _, sent := range server.SentPackets() {
+		options := sent.ParseOptions()
+		if string(options[dhcp4.OptionHostName]) != "host" {
+			test.Errorf("Host Name:%q", options[dhcp4.OptionHostName])
+		}
+		if fqdn, ok := options[dhcp4client.OptionClientFQDN]; ok {
+			test.Errorf("Client FQDN:%v, expected none", fqdn)
+		}
+	}
+}
+
+func Test_FQDNPartialName(test *testing.T) {
+	fqdn, err := dhcp4client.ParseClientFQDN([]byte{byte(dhcp4client.FQDNEncoded), 0, 0, 4, 'h', 'o', 's', 't'})
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if fqdn.Name != "host" {
+		test.Errorf("Name:%q, expected %q", fqdn.Name, "host")
+	}
+
+	ascii, err := dhcp4client.ParseClientFQDN([]byte{0, 0, 0, 'h', 'o', 's', 't'})
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if ascii.Name != "host" {
+		test.Errorf("Name:%q, expected %q", ascii.Name, "host")
+	}
+}
+
+func Test_FQDNInvalid(test *testing.T) {
+	server := newTestServer()
+	c := newTestClient(test, server)
+
+	if err := c.SetOption(dhcp4client.FQDN("host..example.com", 0)); err == nil {
+		test.Error("Expected an error for an empty label")
+	}
+	if err := c.SetOption(dhcp4client.FQDN("host.example.com", dhcp4client.FQDNServerUpdate|dhcp4client.FQDNNoUpdate)); err == nil {
+		test.Error("Expected an error for both the S and N flags")
+	}
+	if _, err := dhcp4client.ParseClientFQDN([]byte{byte(dhcp4client.FQDNEncoded), 0, 0, 9, 'h'}); err == nil {
+		test.Error("Expected an error for a truncated label")
+	}
+}
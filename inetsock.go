@@ -8,14 +8,17 @@ import (
 type inetSock struct {
 	*net.UDPConn
 
-	laddr net.UDPAddr
-	raddr net.UDPAddr
+	laddr          net.UDPAddr
+	raddr          net.UDPAddr
+	maxMessageSize int
+	readTimeout    time.Duration
 }
 
 func NewInetSock(options ...func(*inetSock) error) (*inetSock, error) {
 	c := &inetSock{
-		laddr: net.UDPAddr{IP: net.IPv4(0, 0, 0, 0), Port: 68},
-		raddr: net.UDPAddr{IP: net.IPv4bcast, Port: 67},
+		laddr:          net.UDPAddr{IP: net.IPv4(0, 0, 0, 0), Port: 68},
+		raddr:          net.UDPAddr{IP: net.IPv4bcast, Port: 67},
+		maxMessageSize: MaxDHCPLen,
 	}
 
 	err := c.setOption(options...)
@@ -60,9 +63,28 @@ func (c *inetSock) Write(packet []byte) error {
 	return err
 }
 
+// WriteTo sends the packet to dstIP on the remote port, or to the remote
+// address if dstIP is nil. The kernel resolves the hardware address so dstMAC
+// is ignored.
+func (c *inetSock) WriteTo(packet []byte, dstIP net.IP, dstMAC net.HardwareAddr) error {
+	if dstIP == nil {
+		return c.Write(packet)
+	}
+
+	_, err := c.WriteToUDP(packet, &net.UDPAddr{IP: dstIP, Port: c.raddr.Port})
+	return err
+}
+
 func (c *inetSock) ReadFrom() ([]byte, net.IP, error) {
-	readBuffer := make([]byte, MaxDHCPLen)
+	// one byte larger so we can tell if the packet was truncated
+	readBuffer := make([]byte, c.maxMessageSize+1)
 	n, source, err := c.ReadFromUDP(readBuffer)
+	if networkError, ok := err.(net.Error); ok && networkError.Timeout() {
+		return nil, nil, &TimeoutError{Timeout: c.readTimeout}
+	}
+	if err == nil && n > c.maxMessageSize {
+		return nil, nil, &TruncatedError{Max: c.maxMessageSize}
+	}
 	if source != nil {
 		return readBuffer[:n], source.IP, err
 	} else {
@@ -70,6 +92,12 @@ func (c *inetSock) ReadFrom() ([]byte, net.IP, error) {
 	}
 }
 
+func (c *inetSock) SetMaxMessageSize(n int) error {
+	c.maxMessageSize = n
+	return nil
+}
+
 func (c *inetSock) SetReadTimeout(t time.Duration) error {
+	c.readTimeout = t
 	return c.SetReadDeadline(time.Now().Add(t))
 }
@@ -0,0 +1,38 @@
+//Package atomicfile replaces files atomically, for the lease store and the
+//resolv.conf writers.
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+)
+
+//Write to a temporary file and rename it over path so readers never see a
+//partial file.
+//The temporary file is hidden (starts with a ".") so anything merging the
+//files in the directory, e.g. resolvconf(8), ignores it.
+func WriteFile(path string, b []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
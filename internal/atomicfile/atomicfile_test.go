@@ -0,0 +1,54 @@
+package atomicfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/d2g/dhcp4client/internal/atomicfile"
+)
+
+func Test_WriteFile(test *testing.T) {
+	dir := test.TempDir()
+	path := filepath.Join(dir, "file")
+
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if err := atomicfile.WriteFile(path, []byte("new"), 0644); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if string(b) != "new" {
+		test.Errorf("Contents:%q, expected \"new\"", b)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if info.Mode().Perm() != 0644 {
+		test.Errorf("Mode:%v, expected 0644", info.Mode().Perm())
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if len(files) != 1 {
+		test.Errorf("Files:%v, expected the temporary file to be gone", files)
+	}
+}
+
+//The directory must exist, nothing is left behind when it doesn't.
+func Test_WriteFileMissingDirectory(test *testing.T) {
+	path := filepath.Join(test.TempDir(), "missing", "file")
+
+	if err := atomicfile.WriteFile(path, []byte("new"), 0644); err == nil {
+		test.Error("Expected an error writing to a missing directory")
+	}
+}
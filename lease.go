@@ -0,0 +1,276 @@
+package dhcp4client
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"time"
+
+	"github.com/d2g/dhcp4"
+)
+
+//The network configuration a server hands out in a DHCPACK, either with a
+//Lease or in reply to a DHCPINFORM.
+type Configuration struct {
+	ServerIdentifier net.IP     //Option 54
+	SubnetMask       net.IPMask //Option 1
+	Routers          []net.IP   //Option 3
+	DNSServers       []net.IP   //Option 6
+	DomainName       string     //Option 15
+	DomainSearch     []string   //Option 119
+	NTPServers       []net.IP   //Option 42
+	VendorSpecific   []byte     //Option 43, see ParseVendorOptions and VendorDecoder.
+	MTU              uint16     //Option 26, 0 if it wasn't sent.
+	ClasslessRoutes  []Route    //Option 121, when sent Routers should be ignored (RFC 3442).
+	Warnings         []error    //Options which couldn't be decoded and were left out, each matches ErrMalformedReply.
+}
+
+//A static route, traffic for Destination goes via Gateway or directly on the
+//link if the Gateway is unspecified.
+type Route struct {
+	Destination net.IPNet
+	Gateway     net.IP
+}
+
+//https://tools.ietf.org/html/rfc2132#section-9.2 a Lease Time of 0xffffffff
+//represents infinity.
+const InfiniteLeaseTime = time.Duration(math.MaxUint32) * time.Second
+
+//A Lease decoded from a DHCPACK.
+type Lease struct {
+	Configuration
+
+	FixedAddress  net.IP        //The address assigned to us (yiaddr).
+	LeaseTime     time.Duration //Option 51, InfiniteLeaseTime if it never expires.
+	RenewalTime   time.Duration //Option 58 (T1), defaults to 0.5 of the LeaseTime.
+	RebindingTime time.Duration //Option 59 (T2), defaults to 0.875 of the LeaseTime.
+	Acquired      time.Time     //When the REQUEST which got us the lease was sent.
+	FQDN          *ClientFQDN   //Option 81, the DNS updates the server has done, nil if it didn't say.
+
+	acknowledgement dhcp4.Packet
+}
+
+//Decode a Lease from an Acknowledgement Packet.
+//acquired should be the time the REQUEST was sent as the lease times are relative to it.
+//Only what's needed to hold the Lease is required: an Acknowledgement without
+//an address, a Lease Time or a Server Identifier, or with one that can't be
+//decoded, returns an error matching ErrMalformedReply. Other options which
+//can't be decoded are left out and recorded in the Warnings.
+func NewLease(acknowledgement dhcp4.Packet, acquired time.Time) (Lease, error) {
+	if !isACK(acknowledgement) {
+		return Lease{}, fmt.Errorf("packet is not a DHCPACK")
+	}
+
+	options := ParseReplyOptions(acknowledgement)
+
+	l := Lease{
+		Configuration:   decodeConfiguration(options),
+		FixedAddress:    copyIP(acknowledgement.YIAddr()),
+		Acquired:        acquired,
+		acknowledgement: acknowledgement,
+	}
+
+	if l.FixedAddress.Equal(net.IPv4zero) {
+		return Lease{}, malformed(fmt.Errorf("acknowledgement has no address"))
+	}
+
+	//Decoded again as decodeConfiguration only warns about it.
+	var err error
+	if l.ServerIdentifier, err = optionIP(options, dhcp4.OptionServerIdentifier); err != nil {
+		return Lease{}, malformed(err)
+	}
+	if l.ServerIdentifier == nil {
+		return Lease{}, malformed(fmt.Errorf("acknowledgement has no server identifier"))
+	}
+
+	if l.LeaseTime, err = optionDuration(options, dhcp4.OptionIPAddressLeaseTime, 0); err != nil {
+		return Lease{}, malformed(err)
+	}
+	//https://tools.ietf.org/html/rfc2131#section-4.3.1 the server MUST send the
+	//Lease Time, without it we'd have to renew straight away.
+	if l.LeaseTime == 0 {
+		return Lease{}, malformed(fmt.Errorf("acknowledgement has no lease time"))
+	}
+
+	if b, ok := options[OptionClientFQDN]; ok {
+		if fqdn, err := ParseClientFQDN(b); err != nil {
+			l.warn(err)
+		} else {
+			l.FQDN = &fqdn
+		}
+	}
+
+	if l.RenewalTime, err = optionDuration(options, dhcp4.OptionRenewalTimeValue, l.LeaseTime/2); err != nil {
+		l.warn(err)
+		l.RenewalTime = l.LeaseTime / 2
+	}
+
+	if l.RebindingTime, err = optionDuration(options, dhcp4.OptionRebindingTimeValue, l.LeaseTime*7/8); err != nil {
+		l.warn(err)
+		l.RebindingTime = l.LeaseTime * 7 / 8
+	}
+
+	return l, nil
+}
+
+//The Acknowledgement Packet the Lease was decoded from.
+func (l Lease) Acknowledgement() dhcp4.Packet {
+	return l.acknowledgement
+}
+
+//When to start RENEWING (T1).
+func (l Lease) RenewAt() time.Time {
+	return l.Acquired.Add(l.RenewalTime)
+}
+
+//When to start REBINDING (T2).
+func (l Lease) RebindAt() time.Time {
+	return l.Acquired.Add(l.RebindingTime)
+}
+
+//When the Lease Expires.
+func (l Lease) ExpiresAt() time.Time {
+	return l.Acquired.Add(l.LeaseTime)
+}
+
+//Decode the Configuration from an Acknowledgement's options.
+//Options which can't be decoded are left out and recorded in the Warnings.
+func decodeConfiguration(options dhcp4.Options) Configuration {
+	c := Configuration{
+		DomainName:     string(options[dhcp4.OptionDomainName]),
+		VendorSpecific: append([]byte(nil), options[dhcp4.OptionVendorSpecificInformation]...),
+	}
+
+	var err error
+	if c.ServerIdentifier, err = optionIP(options, dhcp4.OptionServerIdentifier); err != nil {
+		c.warn(err)
+	}
+
+	if mask, err := optionIP(options, dhcp4.OptionSubnetMask); err != nil {
+		c.warn(err)
+	} else if mask != nil {
+		c.SubnetMask = net.IPMask(mask)
+	}
+
+	if c.Routers, err = optionIPs(options, dhcp4.OptionRouter); err != nil {
+		c.warn(err)
+	}
+
+	if c.DNSServers, err = optionIPs(options, dhcp4.OptionDomainNameServer); err != nil {
+		c.warn(err)
+	}
+
+	if c.NTPServers, err = optionIPs(options, dhcp4.OptionNetworkTimeProtocolServers); err != nil {
+		c.warn(err)
+	}
+
+	if b, ok := options[OptionDomainSearch]; ok {
+		if c.DomainSearch, err = ParseDomainSearch(b); err != nil {
+			c.warn(err)
+		}
+	}
+
+	if b, ok := options[dhcp4.OptionInterfaceMTU]; ok {
+		//https://tools.ietf.org/html/rfc2132#section-5.1 the minimum is 68.
+		if len(b) != 2 || binary.BigEndian.Uint16(b) < 68 {
+			c.warn(fmt.Errorf("invalid interface MTU option %v", b))
+		} else {
+			c.MTU = binary.BigEndian.Uint16(b)
+		}
+	}
+
+	if b, ok := options[dhcp4.OptionClasslessRouteFormat]; ok {
+		if c.ClasslessRoutes, err = decodeClasslessRoutes(b); err != nil {
+			c.warn(err)
+		}
+	}
+
+	return c
+}
+
+//Record an option that couldn't be decoded.
+func (c *Configuration) warn(err error) {
+	c.Warnings = append(c.Warnings, malformed(err))
+}
+
+//Decode Classless Static Routes https://tools.ietf.org/html/rfc3442#section-2
+//Each is the prefix length, the significant octets of the destination then
+//the router.
+func decodeClasslessRoutes(b []byte) ([]Route, error) {
+	var routes []Route
+
+	for len(b) > 0 {
+		width := int(b[0])
+		if width > 32 {
+			return nil, fmt.Errorf("classless route has prefix length %d", width)
+		}
+
+		significant := (width + 7) / 8
+		if len(b) < 1+significant+net.IPv4len {
+			return nil, fmt.Errorf("classless route is truncated")
+		}
+
+		destination := make(net.IP, net.IPv4len)
+		copy(destination, b[1:1+significant])
+		mask := net.CIDRMask(width, 32)
+
+		routes = append(routes, Route{
+			Destination: net.IPNet{IP: destination.Mask(mask), Mask: mask},
+			Gateway:     copyIP(net.IP(b[1+significant : 1+significant+net.IPv4len])),
+		})
+		b = b[1+significant+net.IPv4len:]
+	}
+
+	return routes, nil
+}
+
+func isACK(p dhcp4.Packet) bool {
+	options := ParseReplyOptions(p)
+	return len(options[dhcp4.OptionDHCPMessageType]) > 0 && dhcp4.MessageType(options[dhcp4.OptionDHCPMessageType][0]) == dhcp4.ACK
+}
+
+func copyIP(ip net.IP) net.IP {
+	return append(net.IP(nil), ip.To4()...)
+}
+
+//Decode a single address option, nil if it's missing.
+func optionIP(options dhcp4.Options, code dhcp4.OptionCode) (net.IP, error) {
+	b, ok := options[code]
+	if !ok {
+		return nil, nil
+	}
+	if len(b) != net.IPv4len {
+		return nil, fmt.Errorf("option %d has length %d, expected %d", code, len(b), net.IPv4len)
+	}
+	return copyIP(net.IP(b)), nil
+}
+
+//Decode a list of addresses option, nil if it's missing.
+func optionIPs(options dhcp4.Options, code dhcp4.OptionCode) ([]net.IP, error) {
+	b, ok := options[code]
+	if !ok {
+		return nil, nil
+	}
+	if len(b) == 0 || len(b)%net.IPv4len != 0 {
+		return nil, fmt.Errorf("option %d has length %d, expected a multiple of %d", code, len(b), net.IPv4len)
+	}
+
+	ips := make([]net.IP, 0, len(b)/net.IPv4len)
+	for i := 0; i < len(b); i += net.IPv4len {
+		ips = append(ips, copyIP(net.IP(b[i:i+net.IPv4len])))
+	}
+	return ips, nil
+}
+
+//Decode a 32 bit seconds option, def if it's missing.
+func optionDuration(options dhcp4.Options, code dhcp4.OptionCode, def time.Duration) (time.Duration, error) {
+	b, ok := options[code]
+	if !ok {
+		return def, nil
+	}
+	if len(b) != 4 {
+		return 0, fmt.Errorf("option %d has length %d, expected 4", code, len(b))
+	}
+	return time.Duration(binary.BigEndian.Uint32(b)) * time.Second, nil
+}
@@ -0,0 +1,221 @@
+package dhcp4client_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+func testAcknowledgement(options []dhcp4.Option) dhcp4.Packet {
+	request := dhcp4.NewPacket(dhcp4.BootRequest)
+	request.SetXId([]byte{1, 2, 3, 4})
+	return dhcp4.ReplyPacket(request, dhcp4.ACK, net.IPv4(192, 168, 1, 1).To4(), net.IPv4(192, 168, 1, 100), time.Hour, options)
+}
+
+func Test_NewLease(test *testing.T) {
+	acquired := time.Date(2018, 11, 16, 12, 0, 0, 0, time.UTC)
+
+	acknowledgement := testAcknowledgement([]dhcp4.Option{
+		{Code: dhcp4.OptionSubnetMask, Value: []byte{255, 255, 255, 0}},
+		{Code: dhcp4.OptionRouter, Value: []byte{192, 168, 1, 254}},
+		{Code: dhcp4.OptionDomainNameServer, Value: []byte{8, 8, 8, 8, 8, 8, 4, 4}},
+		{Code: dhcp4.OptionDomainName, Value: []byte("example.com")},
+	})
+
+	lease, err := dhcp4client.NewLease(acknowledgement, acquired)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	if !lease.FixedAddress.Equal(net.IPv4(192, 168, 1, 100)) {
+		test.Errorf("FixedAddress:%v", lease.FixedAddress)
+	}
+	if !lease.ServerIdentifier.Equal(net.IPv4(192, 168, 1, 1)) {
+		test.Errorf("ServerIdentifier:%v", lease.ServerIdentifier)
+	}
+	if lease.SubnetMask.String() != "ffffff00" {
+		test.Errorf("SubnetMask:%v", lease.SubnetMask)
+	}
+	if len(lease.Routers) != 1 || !lease.Routers[0].Equal(net.IPv4(192, 168, 1, 254)) {
+		test.Errorf("Routers:%v", lease.Routers)
+	}
+	if len(lease.DNSServers) != 2 || !lease.DNSServers[1].Equal(net.IPv4(8, 8, 4, 4)) {
+		test.Errorf("DNSServers:%v", lease.DNSServers)
+	}
+	if lease.DomainName != "example.com" {
+		test.Errorf("DomainName:%v", lease.DomainName)
+	}
+
+	//T1 and T2 default to 0.5 and 0.875 of the lease.
+	if !lease.RenewAt().Equal(acquired.Add(time.Minute * 30)) {
+		test.Errorf("RenewAt:%v", lease.RenewAt())
+	}
+	if !lease.RebindAt().Equal(acquired.Add(time.Minute * 52).Add(time.Second * 30)) {
+		test.Errorf("RebindAt:%v", lease.RebindAt())
+	}
+	if !lease.ExpiresAt().Equal(acquired.Add(time.Hour)) {
+		test.Errorf("ExpiresAt:%v", lease.ExpiresAt())
+	}
+
+	if string(lease.Acknowledgement()) != string(acknowledgement) {
+		test.Error("Acknowledgement doesn't match the original packet")
+	}
+}
+
+func Test_NewLeaseRenewalTimes(test *testing.T) {
+	acknowledgement := testAcknowledgement([]dhcp4.Option{
+		{Code: dhcp4.OptionRenewalTimeValue, Value: []byte{0, 0, 0, 60}},
+		{Code: dhcp4.OptionRebindingTimeValue, Value: []byte{0, 0, 0, 120}},
+	})
+
+	lease, err := dhcp4client.NewLease(acknowledgement, time.Now())
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	if lease.RenewalTime != time.Minute || lease.RebindingTime != time.Minute*2 {
+		test.Errorf("RenewalTime:%v RebindingTime:%v", lease.RenewalTime, lease.RebindingTime)
+	}
+}
+
+func Test_NewLeaseMalformed(test *testing.T) {
+	noAddress := dhcp4.ReplyPacket(dhcp4.NewPacket(dhcp4.BootRequest), dhcp4.ACK, net.IPv4(192, 168, 1, 1).To4(), net.IPv4zero, time.Hour, nil)
+	if _, err := dhcp4client.NewLease(noAddress, time.Now()); !errors.Is(err, dhcp4client.ErrMalformedReply) {
+		test.Errorf("Expected ErrMalformedReply decoding an acknowledgement without an address, got %v", err)
+	}
+
+	badServer := testAcknowledgement([]dhcp4.Option{
+		{Code: dhcp4.OptionServerIdentifier, Value: []byte{192, 168, 1}},
+	})
+	//Joined (RFC 3396) to the one ReplyPacket adds it has the wrong length.
+	if _, err := dhcp4client.NewLease(badServer, time.Now()); !errors.Is(err, dhcp4client.ErrMalformedReply) {
+		test.Errorf("Expected ErrMalformedReply decoding a truncated server identifier, got %v", err)
+	}
+
+	//Without a Lease Time we'd renew straight away.
+	noLeaseTime := dhcp4.ReplyPacket(dhcp4.NewPacket(dhcp4.BootRequest), dhcp4.ACK, net.IPv4(192, 168, 1, 1).To4(), net.IPv4(192, 168, 1, 100), 0, nil)
+	if _, err := dhcp4client.NewLease(noLeaseTime, time.Now()); !errors.Is(err, dhcp4client.ErrMalformedReply) {
+		test.Errorf("Expected ErrMalformedReply decoding an acknowledgement without a lease time, got %v", err)
+	}
+
+	offer := dhcp4.ReplyPacket(dhcp4.NewPacket(dhcp4.BootRequest), dhcp4.Offer, net.IPv4(192, 168, 1, 1).To4(), net.IPv4(192, 168, 1, 100), time.Hour, nil)
+	if _, err := dhcp4client.NewLease(offer, time.Now()); err == nil {
+		test.Error("Expected an error decoding an offer")
+	}
+}
+
+func Test_NewLeaseRoutesAndMTU(test *testing.T) {
+	acknowledgement := testAcknowledgement([]dhcp4.Option{
+		{Code: dhcp4.OptionInterfaceMTU, Value: []byte{5, 220}},
+		{Code: dhcp4.OptionClasslessRouteFormat, Value: []byte{
+			0, 192, 168, 1, 254, //default via 192.168.1.254
+			24, 10, 1, 2, 0, 0, 0, 0, //10.1.2.0/24 on-link
+			12, 172, 16, 192, 168, 1, 253, //172.16.0.0/12 via 192.168.1.253
+		}},
+	})
+
+	lease, err := dhcp4client.NewLease(acknowledgement, time.Now())
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	if lease.MTU != 1500 {
+		test.Errorf("MTU:%d", lease.MTU)
+	}
+
+	expected := []struct {
+		destination string
+		gateway     net.IP
+	}{
+		{"0.0.0.0/0", net.IPv4(192, 168, 1, 254)},
+		{"10.1.2.0/24", net.IPv4zero},
+		{"172.16.0.0/12", net.IPv4(192, 168, 1, 253)},
+	}
+
+	if len(lease.ClasslessRoutes) != len(expected) {
+		test.Fatalf("ClasslessRoutes:%v", lease.ClasslessRoutes)
+	}
+	for i, route := range lease.ClasslessRoutes {
+		if route.Destination.String() != expected[i].destination || !route.Gateway.Equal(expected[i].gateway) {
+			test.Errorf("Route %d:%v via %v, expected %s via %v", i, route.Destination.String(), route.Gateway, expected[i].destination, expected[i].gateway)
+		}
+	}
+
+}
+
+//Informational options which can't be decoded are left out rather than
+//costing us the Lease.
+func Test_NewLeaseWarnings(test *testing.T) {
+	acknowledgement := testAcknowledgement([]dhcp4.Option{
+		{Code: dhcp4.OptionRouter, Value: []byte{192, 168, 1}},
+		{Code: dhcp4.OptionDomainNameServer, Value: []byte{8, 8, 8, 8}},
+		{Code: dhcp4.OptionNetworkTimeProtocolServers, Value: []byte{}},
+		{Code: dhcp4.OptionInterfaceMTU, Value: []byte{0, 10}},
+		{Code: dhcp4client.OptionDomainSearch, Value: []byte{3, 'c', 'o', 'm'}},
+		{Code: dhcp4.OptionClasslessRouteFormat, Value: []byte{24, 10, 1, 2, 0, 0}},
+		{Code: dhcp4.OptionRenewalTimeValue, Value: []byte{0, 60}},
+		{Code: dhcp4client.OptionClientFQDN, Value: []byte{byte(dhcp4client.FQDNEncoded), 0, 0, 9, 'h'}},
+	})
+
+	lease, err := dhcp4client.NewLease(acknowledgement, time.Now())
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	if !lease.FixedAddress.Equal(net.IPv4(192, 168, 1, 100)) || lease.LeaseTime != time.Hour {
+		test.Errorf("FixedAddress:%v LeaseTime:%v", lease.FixedAddress, lease.LeaseTime)
+	}
+	if len(lease.DNSServers) != 1 || !lease.DNSServers[0].Equal(net.IPv4(8, 8, 8, 8)) {
+		test.Errorf("DNSServers:%v", lease.DNSServers)
+	}
+	if lease.Routers != nil || lease.NTPServers != nil || lease.MTU != 0 || lease.DomainSearch != nil || lease.ClasslessRoutes != nil || lease.FQDN != nil {
+		test.Errorf("Routers:%v NTPServers:%v MTU:%d DomainSearch:%v ClasslessRoutes:%v FQDN:%v", lease.Routers, lease.NTPServers, lease.MTU, lease.DomainSearch, lease.ClasslessRoutes, lease.FQDN)
+	}
+	if lease.RenewalTime != time.Minute*30 {
+		test.Errorf("RenewalTime:%v", lease.RenewalTime)
+	}
+
+	if len(lease.Warnings) != 7 {
+		test.Fatalf("Warnings:%v", lease.Warnings)
+	}
+	for _, warning := range lease.Warnings {
+		if !errors.Is(warning, dhcp4client.ErrMalformedReply) {
+			test.Errorf("Warning %v doesn't match ErrMalformedReply", warning)
+		}
+	}
+}
+
+//The example from https://tools.ietf.org/html/rfc3397#section-3 with the
+//second name compressed.
+func Test_NewLeaseDomainSearch(test *testing.T) {
+	search := []byte{3, 'e', 'n', 'g', 5, 'a', 'p', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0}
+	search = append(search, 9, 'm', 'a', 'r', 'k', 'e', 't', 'i', 'n', 'g', 0xC0, 4)
+
+	lease, err := dhcp4client.NewLease(testAcknowledgement([]dhcp4.Option{
+		{Code: dhcp4client.OptionDomainSearch, Value: search},
+	}), time.Now())
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	if len(lease.DomainSearch) != 2 || lease.DomainSearch[0] != "eng.apple.com" || lease.DomainSearch[1] != "marketing.apple.com" {
+		test.Errorf("DomainSearch:%v", lease.DomainSearch)
+	}
+}
+
+func Test_ParseDomainSearchInvalid(test *testing.T) {
+	for name, b := range map[string][]byte{
+		"truncated":       {3, 'c', 'o', 'm'},
+		"pointer loop":    {3, 'c', 'o', 'm', 0xC0, 0},
+		"forward pointer": {0xC0, 2, 3, 'c', 'o', 'm', 0},
+		"reserved bits":   {0x40, 0},
+	} {
+		if names, err := dhcp4client.ParseDomainSearch(b); err == nil {
+			test.Errorf("%s: expected an error, got %v", name, names)
+		}
+	}
+}
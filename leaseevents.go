@@ -0,0 +1,97 @@
+package dhcp4client
+
+//What happened to a LeaseManager's Lease.
+type EventType int
+
+const (
+	EventBound     EventType = iota //A Lease was acquired, or reclaimed in INIT-REBOOT.
+	EventRenewed                    //The Lease was extended while RENEWING or REBINDING.
+	EventRebinding                  //T2 passed without a renewal, any server may now extend the Lease.
+	EventExpired                    //The Lease expired without being extended.
+	EventNAK                        //A server refused the Lease or the Request for it.
+	EventReleased                   //The Lease was given back with Release.
+	EventConflict                   //The address offered was already in use and was declined.
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventBound:
+		return "BOUND"
+	case EventRenewed:
+		return "RENEWED"
+	case EventRebinding:
+		return "REBINDING"
+	case EventExpired:
+		return "EXPIRED"
+	case EventNAK:
+		return "NAK"
+	case EventReleased:
+		return "RELEASED"
+	case EventConflict:
+		return "CONFLICT"
+	}
+	return "UNKNOWN"
+}
+
+//A change in the life of a Lease.
+//Old is the Lease held before the event and New the Lease held after it,
+//either is the zero Lease if there isn't one. For EventRebinding both are the
+//Lease being rebound and for EventConflict New is the Lease that was declined.
+type Event struct {
+	Type EventType
+	Old  Lease
+	New  Lease
+	Err  error //The *NAKError for EventNAK.
+}
+
+//Events are buffered on the channel from Events until they're received.
+const eventBuffer = 16
+
+//Called (from the Run goroutine, or Release) for every Event. Run waits for f
+//to return so a slow f delays renewals, use Events to receive them on a
+//channel instead.
+func OnEvent(f func(Event)) func(*LeaseManager) error {
+	return func(m *LeaseManager) error {
+		m.onEvent = f
+		return nil
+	}
+}
+
+//A channel receiving every Event from the first call on, it's never closed.
+//Once eventBuffer Events are waiting to be received Run waits for the next to
+//be too (unless it's stopping) so keep receiving. Events are dropped if the
+//buffer is full when Run isn't running to wait.
+func (m *LeaseManager) Events() <-chan Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.events == nil {
+		m.events = make(chan Event, eventBuffer)
+	}
+	return m.events
+}
+
+func (m *LeaseManager) emit(e Event) {
+	if m.onEvent != nil {
+		m.onEvent(e)
+	}
+
+	m.mu.Lock()
+	events, stopping := m.events, m.stopping
+	m.mu.Unlock()
+
+	if events == nil {
+		return
+	}
+
+	select {
+	case events <- e:
+	default:
+		if stopping != nil {
+			select {
+			case events <- e:
+			case <-stopping:
+			}
+		}
+	}
+}
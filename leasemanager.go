@@ -0,0 +1,430 @@
+package dhcp4client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/d2g/dhcp4"
+)
+
+//The RFC 2131 client states a LeaseManager moves through.
+type State int
+
+const (
+	StateInit State = iota
+	StateSelecting
+	StateRequesting
+	StateBound
+	StateRenewing
+	StateRebinding
+	StateInitReboot
+	StateRebooting
+)
+
+func (s State) String() string {
+	switch s {
+	case StateInit:
+		return "INIT"
+	case StateSelecting:
+		return "SELECTING"
+	case StateRequesting:
+		return "REQUESTING"
+	case StateBound:
+		return "BOUND"
+	case StateRenewing:
+		return "RENEWING"
+	case StateRebinding:
+		return "REBINDING"
+	case StateInitReboot:
+		return "INIT-REBOOT"
+	case StateRebooting:
+		return "REBOOTING"
+	}
+	return "UNKNOWN"
+}
+
+const (
+	//https://tools.ietf.org/html/rfc2131#section-4.4.5 the client waits one-half
+	//of the remaining time until T2 (or expiry) but no less than 60 seconds
+	//before retransmitting a DHCPREQUEST while RENEWING or REBINDING.
+	minRenewRetransmit = time.Second * 60
+)
+
+//LeaseManager takes over a Client and drives it through the RFC 2131 state
+//machine: acquiring a lease, renewing it at T1, rebinding at T2 and starting
+//again from INIT when it expires or is NAKed.
+type LeaseManager struct {
+	client        *Client
+	retryInterval time.Duration        //Time to wait before restarting from INIT after a failure.
+	onStateChange func(from, to State) //Called on every state transition.
+	onEvent       func(Event)          //Called on every Event.
+	store         LeaseStore           //Where to persist the Lease, nil to not.
+	iface         string               //The interface the Lease is stored under.
+
+	mu       sync.Mutex
+	state    State
+	offer    dhcp4.Packet
+	lease    Lease
+	events   chan Event         //Where to send Events, nil until Events is called.
+	stop     context.CancelFunc //Stops Run, nil when it isn't running.
+	stopping <-chan struct{}    //Closed when Run is stopping.
+	stopped  chan struct{}      //Closed when Run returns.
+}
+
+func NewLeaseManager(c *Client, options ...func(*LeaseManager) error) (*LeaseManager, error) {
+	m := LeaseManager{
+		client:        c,
+		retryInterval: time.Second * 10,
+		state:         StateInit,
+	}
+
+	err := m.SetOption(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	//Reclaim a stored Lease, unless one was given with PreviousLease.
+	if m.store != nil && m.state == StateInit {
+		lease, err := m.store.Load(m.iface, m.client.identifier())
+		switch {
+		case err == nil && time.Now().Before(lease.ExpiresAt()):
+			m.lease = lease
+			m.state = StateInitReboot
+		case err != nil && !errors.Is(err, ErrNoLease):
+			//Without the stored Lease we just start from INIT.
+			m.storeError(err)
+		}
+	}
+
+	return &m, nil
+}
+
+func (m *LeaseManager) SetOption(options ...func(*LeaseManager) error) error {
+	for _, opt := range options {
+		if err := opt(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//How long to wait before going back to INIT after a failed DISCOVER or REQUEST.
+func RetryInterval(d time.Duration) func(*LeaseManager) error {
+	return func(m *LeaseManager) error {
+		m.retryInterval = d
+		return nil
+	}
+}
+
+//Start in INIT-REBOOT trying to reclaim a previously held Lease instead of
+//running a full DISCOVER.
+func PreviousLease(l Lease) func(*LeaseManager) error {
+	return func(m *LeaseManager) error {
+		m.lease = l
+		m.state = StateInitReboot
+		return nil
+	}
+}
+
+//Save the Lease in the store (under the interface name and the client's
+//identifier) whenever it changes, and start by reclaiming the stored Lease.
+//A Lease that can't be loaded is logged and we start from INIT.
+func Store(s LeaseStore, iface string) func(*LeaseManager) error {
+	return func(m *LeaseManager) error {
+		m.store = s
+		m.iface = iface
+		return nil
+	}
+}
+
+//Called (from the Run goroutine) every time the state changes, Run waits for
+//f to return.
+func OnStateChange(f func(from, to State)) func(*LeaseManager) error {
+	return func(m *LeaseManager) error {
+		m.onStateChange = f
+		return nil
+	}
+}
+
+//The current state.
+func (m *LeaseManager) State() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+//The Lease currently held, the zero Lease if there isn't one.
+func (m *LeaseManager) Lease() Lease {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lease
+}
+
+func (m *LeaseManager) setState(s State) {
+	m.mu.Lock()
+	from := m.state
+	m.state = s
+	m.mu.Unlock()
+
+	if from != s && m.onStateChange != nil {
+		m.onStateChange(from, s)
+	}
+}
+
+//Hold the Lease and move to BOUND.
+func (m *LeaseManager) bind(lease Lease, event EventType) {
+	m.mu.Lock()
+	old := m.lease
+	m.lease = lease
+	m.mu.Unlock()
+
+	if m.client.logger != nil {
+		for _, warning := range lease.Warnings {
+			m.client.logger.Printf("dhcp4client: lease for %v from %v: %v", lease.FixedAddress, lease.ServerIdentifier, warning)
+		}
+	}
+
+	if m.store != nil {
+		m.storeError(m.store.Save(m.iface, m.client.identifier(), lease))
+	}
+
+	m.setState(StateBound)
+	m.emit(Event{Type: event, Old: old, New: lease})
+}
+
+func (m *LeaseManager) unbind() {
+	m.mu.Lock()
+	held := m.lease.FixedAddress != nil
+	m.lease = Lease{}
+	m.offer = nil
+	m.mu.Unlock()
+
+	if m.store != nil && held {
+		m.storeError(m.store.Delete(m.iface, m.client.identifier()))
+	}
+}
+
+//Give up the Lease after a NAK and start again from INIT.
+func (m *LeaseManager) naked(err error) {
+	m.emit(Event{Type: EventNAK, Old: m.Lease(), Err: err})
+	m.setState(StateInit)
+}
+
+//Release the Lease held, sending a DHCPRELEASE to the server and deleting the
+//stored Lease. The next Run starts again from INIT.
+//If Run is running it's stopped first (returning context.Canceled), so it
+//can't be called from OnEvent or OnStateChange.
+func (m *LeaseManager) Release() error {
+	m.mu.Lock()
+	stop, stopped := m.stop, m.stopped
+	m.mu.Unlock()
+
+	if stop != nil {
+		stop()
+		<-stopped
+	}
+
+	lease := m.Lease()
+	if lease.FixedAddress == nil {
+		return nil
+	}
+
+	if err := m.client.Release(lease.Acknowledgement()); err != nil {
+		return err
+	}
+
+	m.unbind()
+	m.setState(StateInit)
+	m.emit(Event{Type: EventReleased, Old: lease})
+	return nil
+}
+
+//Failing to persist (or reclaim) the Lease doesn't stop us getting and using
+//one, just log it.
+func (m *LeaseManager) storeError(err error) {
+	if err != nil && m.client.logger != nil {
+		m.client.logger.Printf("dhcp4client: lease store for %s: %v", m.iface, err)
+	}
+}
+
+//Run the state machine until the context is cancelled (or Release is called).
+//Returns the context's error.
+func (m *LeaseManager) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+
+	m.mu.Lock()
+	m.stop, m.stopping, m.stopped = cancel, ctx.Done(), stopped
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		m.stop, m.stopping, m.stopped = nil, nil, nil
+		m.mu.Unlock()
+
+		cancel()
+		close(stopped)
+	}()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		switch m.State() {
+		case StateInit:
+			m.unbind()
+			m.setState(StateSelecting)
+
+		case StateInitReboot:
+			m.setState(StateRebooting)
+
+		case StateRebooting:
+			success, lease, err := m.client.InitRebootContext(ctx, m.Lease())
+			switch {
+			case err == nil && success:
+				m.bind(lease, EventBound)
+			case isNAK(err):
+				m.naked(err)
+			default:
+				m.retry(ctx)
+			}
+
+		case StateSelecting:
+			start := time.Now()
+			offer, err := m.client.selecting(ctx)
+			if err != nil {
+				m.retry(ctx)
+				continue
+			}
+
+			//With Rapid Commit the server can Acknowledge the Discover.
+			if isACK(offer) {
+				m.acknowledged(ctx, offer, start)
+				continue
+			}
+
+			m.offer = offer
+			m.setState(StateRequesting)
+
+		case StateRequesting:
+			start := time.Now()
+			acknowledgement, err := m.client.requesting(ctx, &m.offer)
+			if err != nil {
+				m.retry(ctx)
+				continue
+			}
+
+			if !isACK(acknowledgement) {
+				m.naked(newNAKError(acknowledgement))
+				continue
+			}
+
+			m.acknowledged(ctx, acknowledgement, start)
+
+		case StateBound:
+			if sleepUntil(ctx, m.Lease().RenewAt()) {
+				m.setState(StateRenewing)
+			}
+
+		case StateRenewing:
+			t2 := m.Lease().RebindAt()
+			if !time.Now().Before(t2) {
+				m.setState(StateRebinding)
+				m.emit(Event{Type: EventRebinding, Old: m.Lease(), New: m.Lease()})
+				continue
+			}
+
+			success, lease, err := m.client.RenewLeaseContext(ctx, m.Lease())
+			switch {
+			case err == nil && success:
+				m.bind(lease, EventRenewed)
+			case isNAK(err):
+				m.naked(err)
+			default:
+				sleepUntil(ctx, retransmitAt(t2))
+			}
+
+		case StateRebinding:
+			expiry := m.Lease().ExpiresAt()
+			if !time.Now().Before(expiry) {
+				m.emit(Event{Type: EventExpired, Old: m.Lease()})
+				m.setState(StateInit)
+				continue
+			}
+
+			success, lease, err := m.client.RebindContext(ctx, m.Lease())
+			switch {
+			case err == nil && success:
+				m.bind(lease, EventRenewed)
+			case isNAK(err):
+				m.naked(err)
+			default:
+				sleepUntil(ctx, retransmitAt(expiry))
+			}
+		}
+	}
+}
+
+//Accept the Acknowledgement to a Request (or Rapid Commit Discover) sent at
+//start, Declining it if the address is in use.
+func (m *LeaseManager) acknowledged(ctx context.Context, acknowledgement dhcp4.Packet, start time.Time) {
+	declined, err := m.client.declineConflict(ctx, &acknowledgement)
+	if err != nil {
+		m.retry(ctx)
+		return
+	}
+	if declined {
+		conflict, _ := NewLease(acknowledgement, start)
+		m.emit(Event{Type: EventConflict, Old: m.Lease(), New: conflict})
+
+		if sleepUntil(ctx, time.Now().Add(m.client.declineBackoff)) {
+			m.setState(StateInit)
+		}
+		return
+	}
+
+	lease, err := NewLease(acknowledgement, start)
+	if err != nil {
+		m.retry(ctx)
+		return
+	}
+
+	m.bind(lease, EventBound)
+}
+
+//Wait for the retry interval then start again from INIT.
+func (m *LeaseManager) retry(ctx context.Context) {
+	if sleepUntil(ctx, time.Now().Add(m.retryInterval)) {
+		m.setState(StateInit)
+	}
+}
+
+//When to retransmit a REQUEST while RENEWING or REBINDING given the deadline
+//for that state.
+func retransmitAt(deadline time.Time) time.Time {
+	remaining := time.Until(deadline)
+	wait := remaining / 2
+	if wait < minRenewRetransmit {
+		wait = minRenewRetransmit
+	}
+	if wait > remaining {
+		wait = remaining
+	}
+	return time.Now().Add(wait)
+}
+
+//Sleep until t, returns false if the context was cancelled first.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,327 @@
+package dhcp4client_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+//Records the states a LeaseManager passes through.
+type stateRecorder struct {
+	mu     sync.Mutex
+	states []dhcp4client.State
+	bound  chan struct{}
+}
+
+func (r *stateRecorder) record(from, to dhcp4client.State) {
+	r.mu.Lock()
+	r.states = append(r.states, to)
+	r.mu.Unlock()
+
+	if to == dhcp4client.StateBound {
+		select {
+		case r.bound <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (r *stateRecorder) get() []dhcp4client.State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]dhcp4client.State(nil), r.states...)
+}
+
+func runLeaseManager(test *testing.T, server *testServer, options ...func(*dhcp4client.LeaseManager) error) (*dhcp4client.LeaseManager, *stateRecorder, context.CancelFunc) {
+	m, err := net.ParseMAC("08-00-27-00-A8-E8")
+	if err != nil {
+		test.Fatalf("MAC Error:%v\n", err)
+	}
+
+	client, err := dhcp4client.New(dhcp4client.HardwareAddr(m), dhcp4client.Connection(server), dhcp4client.Timeout(time.Millisecond*200))
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	recorder := &stateRecorder{bound: make(chan struct{}, 1)}
+	options = append([]func(*dhcp4client.LeaseManager) error{dhcp4client.OnStateChange(recorder.record), dhcp4client.RetryInterval(time.Millisecond * 10)}, options...)
+	manager, err := dhcp4client.NewLeaseManager(client, options...)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		manager.Run(ctx)
+		close(done)
+	}()
+
+	//Stop the manager and wait for Run to return.
+	return manager, recorder, func() {
+		cancel()
+		<-done
+	}
+}
+
+func waitForBound(test *testing.T, recorder *stateRecorder) {
+	select {
+	case <-recorder.bound:
+	case <-time.After(time.Second * 5):
+		test.Fatalf("Never Bound, States:%v\n", recorder.get())
+	}
+}
+
+//Check the manager started by going through the expected states.
+func expectStates(test *testing.T, recorder *stateRecorder, expected []dhcp4client.State) {
+	states := recorder.get()
+	if len(states) < len(expected) {
+		test.Fatalf("States:%v, expected %v", states, expected)
+	}
+	for i := range expected {
+		if states[i] != expected[i] {
+			test.Fatalf("States:%v, expected %v", states, expected)
+		}
+	}
+}
+
+func Test_LeaseManagerRenews(test *testing.T) {
+	server := newTestServer()
+	server.LeaseTime = time.Second * 2
+
+	manager, recorder, cancel := runLeaseManager(test, server)
+	defer cancel()
+
+	waitForBound(test, recorder)
+	if !manager.Lease().FixedAddress.Equal(server.ClientIP) {
+		test.Errorf("Bound to %v, expected %v", manager.Lease().FixedAddress, server.ClientIP)
+	}
+
+	//T1 is half the lease.
+	waitForBound(test, recorder)
+
+	expected := []dhcp4client.State{dhcp4client.StateSelecting, dhcp4client.StateRequesting, dhcp4client.StateBound, dhcp4client.StateRenewing, dhcp4client.StateBound}
+	expectStates(test, recorder, expected)
+}
+
+func Test_LeaseManagerRestartsOnNAK(test *testing.T) {
+	server := newTestServer()
+	server.LeaseTime = time.Second * 2
+
+	naked := false
+	server.Reply = func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+		options := request.ParseOptions()
+		//NAK the first renewal (the REQUEST carrying ciaddr).
+		if !naked && dhcp4.MessageType(options[dhcp4.OptionDHCPMessageType][0]) == dhcp4.Request && !request.CIAddr().Equal(net.IPv4zero) {
+			naked = true
+			return nak(request, reply)
+		}
+		return []dhcp4.Packet{reply}
+	}
+
+	events := &eventRecorder{}
+	_, recorder, cancel := runLeaseManager(test, server, dhcp4client.OnEvent(events.record))
+	defer cancel()
+
+	waitForBound(test, recorder)
+	waitForBound(test, recorder)
+
+	expectEvents(test, events, []dhcp4client.EventType{dhcp4client.EventBound, dhcp4client.EventNAK, dhcp4client.EventBound})
+	nak := events.get()[1]
+	var nakError *dhcp4client.NAKError
+	if !errors.As(nak.Err, &nakError) || !nak.Old.FixedAddress.Equal(server.ClientIP) {
+		test.Errorf("NAK Event:%+v", nak)
+	}
+
+	expected := []dhcp4client.State{dhcp4client.StateSelecting, dhcp4client.StateRequesting, dhcp4client.StateBound, dhcp4client.StateRenewing, dhcp4client.StateInit, dhcp4client.StateSelecting, dhcp4client.StateRequesting, dhcp4client.StateBound}
+	expectStates(test, recorder, expected)
+}
+
+func Test_LeaseManagerInitReboot(test *testing.T) {
+	server := newTestServer()
+
+	previous, err := dhcp4client.NewLease(testAcknowledgement(nil), time.Now())
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	_, recorder, cancel := runLeaseManager(test, server, dhcp4client.PreviousLease(previous))
+	defer cancel()
+
+	waitForBound(test, recorder)
+
+	expected := []dhcp4client.State{dhcp4client.StateRebooting, dhcp4client.StateBound}
+	expectStates(test, recorder, expected)
+}
+
+func Test_LeaseManagerRebinds(test *testing.T) {
+	server := newTestServer()
+	server.LeaseTime = time.Second * 2
+
+	//The original server has gone away, only answer requests without a server identifier.
+	server.Reply = func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+		if _, ok := request.ParseOptions()[dhcp4.OptionServerIdentifier]; ok && !request.CIAddr().Equal(net.IPv4zero) {
+			return nil
+		}
+		return []dhcp4.Packet{reply}
+	}
+
+	events := &eventRecorder{}
+	_, recorder, cancel := runLeaseManager(test, server, dhcp4client.OnEvent(events.record))
+	defer cancel()
+
+	waitForBound(test, recorder)
+	waitForBound(test, recorder)
+
+	expectEvents(test, events, []dhcp4client.EventType{dhcp4client.EventBound, dhcp4client.EventRebinding, dhcp4client.EventRenewed})
+
+	expected := []dhcp4client.State{dhcp4client.StateSelecting, dhcp4client.StateRequesting, dhcp4client.StateBound, dhcp4client.StateRenewing, dhcp4client.StateRebinding, dhcp4client.StateBound}
+	expectStates(test, recorder, expected)
+}
+
+//Records the Events from a LeaseManager.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []dhcp4client.Event
+}
+
+func (r *eventRecorder) record(e dhcp4client.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *eventRecorder) get() []dhcp4client.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]dhcp4client.Event(nil), r.events...)
+}
+
+//Check the manager started with the expected Events.
+func expectEvents(test *testing.T, recorder *eventRecorder, expected []dhcp4client.EventType) {
+	events := recorder.get()
+	if len(events) < len(expected) {
+		test.Fatalf("Events:%v, expected %v", events, expected)
+	}
+	for i := range expected {
+		if events[i].Type != expected[i] {
+			test.Fatalf("Events:%v, expected %v", events, expected)
+		}
+	}
+}
+
+func Test_LeaseManagerEvents(test *testing.T) {
+	server := newTestServer()
+	server.LeaseTime = time.Second * 2
+
+	events := &eventRecorder{}
+	manager, recorder, cancel := runLeaseManager(test, server, dhcp4client.OnEvent(events.record))
+
+	waitForBound(test, recorder)
+	waitForBound(test, recorder)
+	cancel()
+
+	if err := manager.Release(); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	expectEvents(test, events, []dhcp4client.EventType{dhcp4client.EventBound, dhcp4client.EventRenewed, dhcp4client.EventReleased})
+
+	all := events.get()
+	bound, renewed, released := all[0], all[1], all[len(all)-1]
+	if bound.Old.FixedAddress != nil || !bound.New.FixedAddress.Equal(server.ClientIP) {
+		test.Errorf("Bound Event:%+v", bound)
+	}
+	if !renewed.Old.FixedAddress.Equal(server.ClientIP) || !renewed.New.FixedAddress.Equal(server.ClientIP) || !renewed.New.Acquired.After(renewed.Old.Acquired) {
+		test.Errorf("Renewed Event:%+v", renewed)
+	}
+	if released.Type != dhcp4client.EventReleased || !released.Old.FixedAddress.Equal(server.ClientIP) || released.New.FixedAddress != nil {
+		test.Errorf("Released Event:%+v", released)
+	}
+
+	if manager.State() != dhcp4client.StateInit || manager.Lease().FixedAddress != nil {
+		test.Errorf("State:%v Lease:%v after Release", manager.State(), manager.Lease().FixedAddress)
+	}
+
+	sent := server.Sent[len(server.Sent)-1]
+	if options := sent.ParseOptions(); dhcp4.MessageType(options[dhcp4.OptionDHCPMessageType][0]) != dhcp4.Release {
+		test.Errorf("Last packet sent wasn't a RELEASE:%v", options)
+	}
+}
+
+//Release stops Run before giving the Lease back, so it doesn't go on to
+//acquire another.
+func Test_LeaseManagerReleaseWhileRunning(test *testing.T) {
+	server := newTestServer()
+
+	manager, recorder, cancel := runLeaseManager(test, server)
+	defer cancel()
+
+	waitForBound(test, recorder)
+	if err := manager.Release(); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	if manager.State() != dhcp4client.StateInit || manager.Lease().FixedAddress != nil {
+		test.Errorf("State:%v Lease:%v after Release", manager.State(), manager.Lease().FixedAddress)
+	}
+
+	time.Sleep(time.Millisecond * 50)
+	server.mu.Lock()
+	sent := server.Sent[len(server.Sent)-1]
+	server.mu.Unlock()
+	if options := sent.ParseOptions(); dhcp4.MessageType(options[dhcp4.OptionDHCPMessageType][0]) != dhcp4.Release {
+		test.Errorf("Last packet sent wasn't a RELEASE:%v", options)
+	}
+}
+
+func Test_LeaseManagerEventsChannel(test *testing.T) {
+	server := newTestServer()
+	server.LeaseTime = time.Second * 2
+
+	manager, err := dhcp4client.NewLeaseManager(newTestClient(test, server))
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	events := manager.Events()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- manager.Run(ctx)
+	}()
+
+	for _, expected := range []dhcp4client.EventType{dhcp4client.EventBound, dhcp4client.EventRenewed} {
+		select {
+		case e := <-events:
+			if e.Type != expected {
+				test.Fatalf("Event:%v, expected %v", e.Type, expected)
+			}
+		case <-time.After(time.Second * 5):
+			test.Fatalf("No Event, expected %v", expected)
+		}
+	}
+
+	if err := manager.Release(); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		test.Errorf("Run Error:%v, expected %v", err, context.Canceled)
+	}
+
+	select {
+	case e := <-events:
+		if e.Type != dhcp4client.EventReleased {
+			test.Errorf("Event:%v, expected %v", e.Type, dhcp4client.EventReleased)
+		}
+	default:
+		test.Errorf("No Event, expected %v", dhcp4client.EventReleased)
+	}
+}
@@ -0,0 +1,199 @@
+package dhcp4client
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/d2g/dhcp4client/internal/atomicfile"
+)
+
+//There's no Lease stored for the interface and client.
+var ErrNoLease = errors.New("no stored lease")
+
+//The FileLeaseStore's file couldn't be decoded.
+var errCorruptLeaseStore = errors.New("corrupt lease store")
+
+//Persists Leases so they can be reclaimed (INIT-REBOOT) after a restart.
+//Leases are keyed by the interface name and client identifier.
+type LeaseStore interface {
+	Save(iface string, clientID []byte, l Lease) error
+	//Returns ErrNoLease if there isn't one.
+	Load(iface string, clientID []byte) (Lease, error)
+	//Deleting a Lease that isn't stored isn't an error.
+	Delete(iface string, clientID []byte) error
+}
+
+//The Lease as it's stored, everything else is decoded from the Acknowledgement.
+type storedLease struct {
+	Acknowledgement []byte    `json:"acknowledgement"`
+	Acquired        time.Time `json:"acquired"`
+}
+
+func newStoredLease(l Lease) storedLease {
+	return storedLease{
+		Acknowledgement: l.Acknowledgement(),
+		Acquired:        l.Acquired,
+	}
+}
+
+func (s storedLease) lease() (Lease, error) {
+	return NewLease(s.Acknowledgement, s.Acquired)
+}
+
+func leaseKey(iface string, clientID []byte) string {
+	return iface + "/" + hex.EncodeToString(clientID)
+}
+
+//A LeaseStore in memory, for tests.
+type MemoryLeaseStore struct {
+	mu     sync.Mutex
+	leases map[string]storedLease
+}
+
+func NewMemoryLeaseStore() *MemoryLeaseStore {
+	return &MemoryLeaseStore{
+		leases: make(map[string]storedLease),
+	}
+}
+
+func (s *MemoryLeaseStore) Save(iface string, clientID []byte, l Lease) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leases[leaseKey(iface, clientID)] = newStoredLease(l)
+	return nil
+}
+
+func (s *MemoryLeaseStore) Load(iface string, clientID []byte) (Lease, error) {
+	s.mu.Lock()
+	stored, ok := s.leases[leaseKey(iface, clientID)]
+	s.mu.Unlock()
+
+	if !ok {
+		return Lease{}, ErrNoLease
+	}
+	return stored.lease()
+}
+
+func (s *MemoryLeaseStore) Delete(iface string, clientID []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.leases, leaseKey(iface, clientID))
+	return nil
+}
+
+//A LeaseStore keeping every Lease in one JSON file.
+//The file is replaced atomically and, where supported, locked (with a ".lock"
+//file alongside it) so several processes can share it.
+type FileLeaseStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileLeaseStore(path string) *FileLeaseStore {
+	return &FileLeaseStore{
+		path: path,
+	}
+}
+
+func (s *FileLeaseStore) Save(iface string, clientID []byte, l Lease) error {
+	return s.update(func(leases map[string]storedLease) {
+		leases[leaseKey(iface, clientID)] = newStoredLease(l)
+	})
+}
+
+func (s *FileLeaseStore) Load(iface string, clientID []byte) (Lease, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return Lease{}, err
+	}
+	defer unlock()
+
+	leases, err := s.read()
+	if err != nil {
+		return Lease{}, err
+	}
+
+	stored, ok := leases[leaseKey(iface, clientID)]
+	if !ok {
+		return Lease{}, ErrNoLease
+	}
+	return stored.lease()
+}
+
+func (s *FileLeaseStore) Delete(iface string, clientID []byte) error {
+	return s.update(func(leases map[string]storedLease) {
+		delete(leases, leaseKey(iface, clientID))
+	})
+}
+
+//Read, change and write back the stored Leases holding the lock.
+func (s *FileLeaseStore) update(change func(map[string]storedLease)) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	//Start again rather than never storing another Lease.
+	leases, err := s.read()
+	if errors.Is(err, errCorruptLeaseStore) {
+		leases = make(map[string]storedLease)
+	} else if err != nil {
+		return err
+	}
+
+	change(leases)
+	return s.write(leases)
+}
+
+func (s *FileLeaseStore) lock() (unlock func(), err error) {
+	s.mu.Lock()
+
+	f, err := os.OpenFile(s.path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	return func() {
+		unlockFile(f)
+		f.Close()
+		s.mu.Unlock()
+	}, nil
+}
+
+func (s *FileLeaseStore) read() (map[string]storedLease, error) {
+	leases := make(map[string]storedLease)
+
+	b, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return leases, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &leases); err != nil {
+		return nil, fmt.Errorf("%w %s: %v", errCorruptLeaseStore, s.path, err)
+	}
+	return leases, nil
+}
+
+func (s *FileLeaseStore) write(leases map[string]storedLease) error {
+	b, err := json.MarshalIndent(leases, "", "\t")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.path, b, 0600)
+}
@@ -0,0 +1,115 @@
+package dhcp4client_test
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d2g/dhcp4client"
+)
+
+func Test_FileLeaseStore(test *testing.T) {
+	path := filepath.Join(test.TempDir(), "leases.json")
+	clientID := []byte{1, 8, 0, 39, 0, 168, 232}
+
+	store := dhcp4client.NewFileLeaseStore(path)
+	if _, err := store.Load("eth0", clientID); !errors.Is(err, dhcp4client.ErrNoLease) {
+		test.Fatalf("Error:%v, expected %v\n", err, dhcp4client.ErrNoLease)
+	}
+
+	acquired := time.Date(2018, 11, 16, 12, 0, 0, 0, time.UTC)
+	lease, err := dhcp4client.NewLease(testAcknowledgement(nil), acquired)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	if err := store.Save("eth0", clientID, lease); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	//A new store, as after a restart, reads the same file.
+	loaded, err := dhcp4client.NewFileLeaseStore(path).Load("eth0", clientID)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if !loaded.FixedAddress.Equal(net.IPv4(192, 168, 1, 100)) || !loaded.Acquired.Equal(acquired) || loaded.LeaseTime != time.Hour {
+		test.Errorf("Loaded FixedAddress:%v Acquired:%v LeaseTime:%v", loaded.FixedAddress, loaded.Acquired, loaded.LeaseTime)
+	}
+
+	if _, err := store.Load("eth1", clientID); !errors.Is(err, dhcp4client.ErrNoLease) {
+		test.Errorf("Error:%v, expected %v for another interface", err, dhcp4client.ErrNoLease)
+	}
+
+	if err := store.Delete("eth0", clientID); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if _, err := store.Load("eth0", clientID); !errors.Is(err, dhcp4client.ErrNoLease) {
+		test.Errorf("Error:%v, expected %v after Delete", err, dhcp4client.ErrNoLease)
+	}
+
+	//Only the store and its lock file should be left behind.
+	files, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if len(files) != 2 {
+		test.Errorf("Files:%v, expected the store and lock file", files)
+	}
+}
+
+func Test_LeaseManagerStore(test *testing.T) {
+	server := newTestServer()
+	store := dhcp4client.NewMemoryLeaseStore()
+
+	manager, recorder, cancel := runLeaseManager(test, server, dhcp4client.Store(store, "eth0"))
+	waitForBound(test, recorder)
+	cancel()
+
+	m, err := net.ParseMAC("08-00-27-00-A8-E8")
+	if err != nil {
+		test.Fatalf("MAC Error:%v\n", err)
+	}
+
+	stored, err := store.Load("eth0", m)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if !stored.Acquired.Equal(manager.Lease().Acquired) {
+		test.Errorf("Stored Lease acquired %v, expected %v", stored.Acquired, manager.Lease().Acquired)
+	}
+
+	//Restarting reclaims the stored Lease.
+	_, recorder, cancel = runLeaseManager(test, server, dhcp4client.Store(store, "eth0"))
+	defer cancel()
+
+	waitForBound(test, recorder)
+	expectStates(test, recorder, []dhcp4client.State{dhcp4client.StateRebooting, dhcp4client.StateBound})
+}
+
+//A store that can't be decoded doesn't stop us starting, or storing the next
+//Lease.
+func Test_LeaseManagerCorruptStore(test *testing.T) {
+	path := filepath.Join(test.TempDir(), "leases.json")
+	if err := os.WriteFile(path, []byte(`{"eth0/01080027`), 0600); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	store := dhcp4client.NewFileLeaseStore(path)
+
+	server := newTestServer()
+	_, recorder, cancel := runLeaseManager(test, server, dhcp4client.Store(store, "eth0"))
+	defer cancel()
+
+	waitForBound(test, recorder)
+	expectStates(test, recorder, []dhcp4client.State{dhcp4client.StateSelecting, dhcp4client.StateRequesting, dhcp4client.StateBound})
+
+	m, err := net.ParseMAC("08-00-27-00-A8-E8")
+	if err != nil {
+		test.Fatalf("MAC Error:%v\n", err)
+	}
+	if _, err := store.Load("eth0", m); err != nil {
+		test.Errorf("Error:%v\n", err)
+	}
+}
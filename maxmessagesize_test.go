@@ -0,0 +1,124 @@
+package dhcp4client_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+func Test_MaxMessageSizeOption(test *testing.T) {
+	server := newTestServer()
+
+	_, err := dhcp4client.New(dhcp4client.Connection(server), dhcp4client.MaxMessageSize(500))
+	if err == nil {
+		test.Error("Expected an error for a maximum message size under 576")
+	}
+
+	//The testServer doesn't implement MaxMessageSizer.
+	_, err = dhcp4client.New(dhcp4client.Connection(server), dhcp4client.MaxMessageSize(1500))
+	if err == nil {
+		test.Error("Expected an error for a connection which can't be resized")
+	}
+
+	//Nor once the Client has been created, when it mustn't be advertised.
+	c, err := dhcp4client.New(dhcp4client.Connection(server))
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if err := c.SetOption(dhcp4client.MaxMessageSize(1500)); err == nil {
+		test.Error("Expected an error for a connection which can't be resized")
+	}
+	discoveryPacket := c.DiscoverPacket()
+	if size, ok := discoveryPacket.ParseOptions()[dhcp4.OptionMaximumDHCPMessageSize]; ok {
+		test.Errorf("Maximum DHCP Message Size:%v, expected none", size)
+	}
+}
+
+//Setting the size after New resizes the connection.
+func Test_MaxMessageSizeAfterNew(test *testing.T) {
+	sender, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	defer sender.Close()
+
+	c, err := dhcp4client.NewInetSock(dhcp4client.SetLocalAddr(net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}), dhcp4client.SetRemoteAddr(*sender.LocalAddr().(*net.UDPAddr)))
+	if err != nil {
+		test.Fatalf("Client Connection Generation:%v\n", err)
+	}
+	defer c.Close()
+
+	exampleClient, err := dhcp4client.New(dhcp4client.Connection(c))
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if err := exampleClient.SetOption(dhcp4client.MaxMessageSize(1500)); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	large := make([]byte, 1000)
+	if _, err := sender.WriteToUDP(large, c.LocalAddr().(*net.UDPAddr)); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	c.SetReadTimeout(time.Second)
+	packet, _, err := c.ReadFrom()
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if len(packet) != len(large) {
+		test.Errorf("Read %d bytes, expected %d", len(packet), len(large))
+	}
+}
+
+func Test_InetSockMaxMessageSize(test *testing.T) {
+	sender, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	defer sender.Close()
+
+	c, err := dhcp4client.NewInetSock(dhcp4client.SetLocalAddr(net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}), dhcp4client.SetRemoteAddr(*sender.LocalAddr().(*net.UDPAddr)))
+	if err != nil {
+		test.Fatalf("Client Connection Generation:%v\n", err)
+	}
+	defer c.Close()
+
+	exampleClient, err := dhcp4client.New(dhcp4client.Connection(c), dhcp4client.MaxMessageSize(1500))
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	discoveryPacket := exampleClient.DiscoverPacket()
+	if size := discoveryPacket.ParseOptions()[dhcp4.OptionMaximumDHCPMessageSize]; !bytes.Equal(size, []byte{5, 220}) {
+		test.Errorf("Maximum DHCP Message Size:%v, expected 1500", size)
+	}
+
+	large := make([]byte, 1000)
+	if _, err := sender.WriteToUDP(large, c.LocalAddr().(*net.UDPAddr)); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	c.SetReadTimeout(time.Second)
+	packet, _, err := c.ReadFrom()
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if len(packet) != len(large) {
+		test.Errorf("Read %d bytes, expected %d", len(packet), len(large))
+	}
+
+	tooLarge := make([]byte, 1501)
+	if _, err := sender.WriteToUDP(tooLarge, c.LocalAddr().(*net.UDPAddr)); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	_, _, err = c.ReadFrom()
+	if _, ok := err.(*dhcp4client.TruncatedError); !ok {
+		test.Errorf("Error:%v, expected a *TruncatedError", err)
+	}
+}
@@ -0,0 +1,377 @@
+package netconf
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/d2g/dhcp4client"
+	"golang.org/x/sys/unix"
+)
+
+//Configures a network interface from DHCP Leases and removes everything it
+//added again. Not safe for concurrent use.
+type Configurator struct {
+	ifindex int
+	name    string
+	netlink *netlink
+	dns     DNSConfigurator
+
+	lease    *dhcp4client.Lease     //The Lease applied, nil if there isn't one.
+	replaced map[string]kernelRoute //Routes the Lease's replaced, by destination, to put back when it's removed.
+	mtu      int                    //The MTU before it was changed, 0 if it hasn't been.
+}
+
+//Configure the interface with the index, as used by NewPacketSock.
+func NewConfigurator(ifindex int, options ...func(*Configurator) error) (*Configurator, error) {
+	iface, err := net.InterfaceByIndex(ifindex)
+	if err != nil {
+		return nil, err
+	}
+
+	c := Configurator{
+		ifindex: ifindex,
+		name:    iface.Name,
+	}
+
+	if err := c.SetOption(options...); err != nil {
+		return nil, err
+	}
+
+	c.netlink, err = dialNetlink()
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (c *Configurator) SetOption(options ...func(*Configurator) error) error {
+	for _, opt := range options {
+		if err := opt(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Configure the resolver with the Lease's DNS servers, Domain Name and Domain
+//Search list, e.g. with NewResolvConf.
+func DNS(d DNSConfigurator) func(*Configurator) error {
+	return func(c *Configurator) error {
+		c.dns = d
+		return nil
+	}
+}
+
+func (c *Configurator) Close() error {
+	return c.netlink.Close()
+}
+
+//Apply a Lease to the interface: add the address with the Lease's lifetime,
+//install the classless static routes (or a default route via the first
+//router without them), configure DNS and set the MTU.
+//Applying a renewed Lease only changes what differs from the Lease applied
+//before: the address lifetime is updated, routes whose gateway changed are
+//replaced, routes the server no longer sends are removed and DNS is only
+//configured if it changed.
+//If any change fails those already made are undone, leaving the interface as
+//it was.
+//A route the interface already had to a Lease route's destination is put back
+//when the Lease's route is removed.
+func (c *Configurator) Apply(l dhcp4client.Lease) error {
+	t := &transaction{}
+	replaced, err := c.apply(t, l)
+	if err != nil {
+		return rollbackError(err, t.rollback())
+	}
+
+	c.lease = &l
+	c.replaced = replaced
+	return nil
+}
+
+//Make the changes for the Lease, returning the routes it replaced.
+func (c *Configurator) apply(t *transaction, l dhcp4client.Lease) (map[string]kernelRoute, error) {
+	address := leaseAddress(l)
+	lifetime := leaseLifetime(l)
+	routes := leaseRoutes(l)
+
+	var previous *net.IPNet
+	var previousLifetime uint32
+	var previousRoutes []dhcp4client.Route
+	var previousDNS DNSConfig
+	if c.lease != nil {
+		a := leaseAddress(*c.lease)
+		previous = &a
+		previousLifetime = leaseLifetime(*c.lease)
+		previousRoutes = leaseRoutes(*c.lease)
+		previousDNS = NewDNSConfig(c.lease.Configuration)
+	}
+
+	//The routes use the address as their source so they all change with it.
+	moved := previous != nil && previous.String() != address.String()
+
+	for _, route := range previousRoutes {
+		if !moved && findRoute(routes, route.Destination) != nil {
+			continue
+		}
+		if err := t.do(c.removeRoute(route), c.addRoute(route, previous.IP)); err != nil {
+			return nil, err
+		}
+	}
+
+	if moved {
+		if err := t.do(c.deleteAddress(*previous), c.addAddress(*previous, previousLifetime)); err != nil {
+			return nil, err
+		}
+	}
+
+	//Adding the address again only updates its lifetime. An address the
+	//interface already had (e.g. the Lease was reclaimed after a restart) gets
+	//its own lifetime back rather than being deleted.
+	undo := c.deleteAddress(address)
+	if previous != nil && !moved {
+		undo = c.addAddress(address, previousLifetime)
+	} else if existing, ok, err := lookupAddress(c.ifindex, address); err != nil {
+		return nil, err
+	} else if ok {
+		undo = c.restoreAddress(address, existing)
+	}
+	if err := t.do(c.addAddress(address, lifetime), undo); err != nil {
+		return nil, err
+	}
+
+	replaced := make(map[string]kernelRoute)
+	for _, route := range routes {
+		undo := c.deleteRoute(route)
+		if old := findRoute(previousRoutes, route.Destination); !moved && old != nil {
+			if original, ok := c.replaced[route.Destination.String()]; ok {
+				replaced[route.Destination.String()] = original
+			}
+			if old.Gateway.Equal(route.Gateway) {
+				continue
+			}
+			//Replaces the route with the old gateway.
+			undo = c.addRoute(*old, address.IP)
+		} else if existing, ok, err := lookupRoute(route.Destination); err != nil {
+			return nil, err
+		} else if ok {
+			//Replaces a route we didn't add, put it back. One that's the same
+			//as ours (e.g. added before a restart) is ours to remove.
+			undo = c.restoreRoute(route.Destination, existing)
+			if !existing.equal(c.ifindex, route.Gateway) {
+				replaced[route.Destination.String()] = existing
+			}
+		}
+
+		if err := t.do(c.addRoute(route, address.IP), undo); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.applyDNS(t, previousDNS, NewDNSConfig(l.Configuration)); err != nil {
+		return nil, err
+	}
+
+	if err := c.applyMTU(t, int(l.MTU)); err != nil {
+		return nil, err
+	}
+	return replaced, nil
+}
+
+//Configure DNS if it's changed, removing it if the Lease doesn't have any.
+func (c *Configurator) applyDNS(t *transaction, previous DNSConfig, config DNSConfig) error {
+	if c.dns == nil || config.equal(previous) {
+		return nil
+	}
+
+	undo := c.removeDNS()
+	if !previous.empty() {
+		undo = c.setDNS(previous)
+	}
+
+	if config.empty() {
+		return t.do(c.removeDNS(), undo)
+	}
+	return t.do(c.setDNS(config), undo)
+}
+
+//Set the MTU, or restore the original if the Lease doesn't have one.
+//Must be the last change as it remembers the original MTU.
+func (c *Configurator) applyMTU(t *transaction, mtu int) error {
+	iface, err := net.InterfaceByIndex(c.ifindex)
+	if err != nil {
+		return err
+	}
+
+	original := c.mtu
+	switch {
+	case mtu == 0:
+		mtu, original = original, 0
+	case original == 0 && mtu != iface.MTU:
+		original = iface.MTU
+	}
+
+	if mtu != 0 && mtu != iface.MTU {
+		if err := t.do(c.setMTU(mtu), c.setMTU(iface.MTU)); err != nil {
+			return err
+		}
+	}
+
+	c.mtu = original
+	return nil
+}
+
+//Remove everything added to the interface, for when the Lease is released or
+//expires. Carries on past errors, returning the first.
+func (c *Configurator) Remove() error {
+	var first error
+	record := func(err error) {
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+
+	if c.lease != nil {
+		for _, route := range leaseRoutes(*c.lease) {
+			record(c.removeRoute(route)())
+		}
+		record(c.deleteAddress(leaseAddress(*c.lease))())
+		if c.dns != nil && !NewDNSConfig(c.lease.Configuration).empty() {
+			record(c.removeDNS()())
+		}
+		c.lease = nil
+		c.replaced = nil
+	}
+
+	if c.mtu > 0 {
+		record(c.setMTU(c.mtu)())
+		c.mtu = 0
+	}
+
+	return first
+}
+
+func (c *Configurator) addAddress(address net.IPNet, lifetime uint32) func() error {
+	return func() error {
+		return c.netlink.execute(addressRequest(unix.RTM_NEWADDR, c.ifindex, address, lifetime, lifetime))
+	}
+}
+
+func (c *Configurator) deleteAddress(address net.IPNet) func() error {
+	return func() error {
+		return ignoreMissing(c.netlink.execute(addressRequest(unix.RTM_DELADDR, c.ifindex, address, 0, 0)))
+	}
+}
+
+func (c *Configurator) restoreAddress(address net.IPNet, a kernelAddress) func() error {
+	return func() error {
+		return c.netlink.execute(addressRequest(unix.RTM_NEWADDR, c.ifindex, address, a.valid, a.preferred))
+	}
+}
+
+func (c *Configurator) addRoute(route dhcp4client.Route, source net.IP) func() error {
+	return func() error {
+		return c.netlink.execute(routeRequest(unix.RTM_NEWROUTE, c.ifindex, route.Destination, route.Gateway, source))
+	}
+}
+
+func (c *Configurator) deleteRoute(route dhcp4client.Route) func() error {
+	return func() error {
+		return ignoreMissing(c.netlink.execute(routeRequest(unix.RTM_DELROUTE, c.ifindex, route.Destination, route.Gateway, nil)))
+	}
+}
+
+//Delete the Lease's route, putting back the route it replaced if there was one.
+func (c *Configurator) removeRoute(route dhcp4client.Route) func() error {
+	if original, ok := c.replaced[route.Destination.String()]; ok {
+		return c.restoreRoute(route.Destination, original)
+	}
+	return c.deleteRoute(route)
+}
+
+func (c *Configurator) restoreRoute(destination net.IPNet, r kernelRoute) func() error {
+	return func() error {
+		return c.netlink.execute(routeRequest(unix.RTM_NEWROUTE, r.ifindex, destination, r.gateway, r.source))
+	}
+}
+
+func (c *Configurator) setDNS(config DNSConfig) func() error {
+	return func() error {
+		return c.dns.SetDNS(c.name, config)
+	}
+}
+
+func (c *Configurator) removeDNS() func() error {
+	return func() error {
+		return c.dns.RemoveDNS(c.name)
+	}
+}
+
+func (c *Configurator) setMTU(mtu int) func() error {
+	return func() error {
+		return c.netlink.execute(mtuRequest(c.ifindex, mtu))
+	}
+}
+
+//The route to destination, nil if there isn't one.
+func findRoute(routes []dhcp4client.Route, destination net.IPNet) *dhcp4client.Route {
+	for i := range routes {
+		if routes[i].Destination.String() == destination.String() {
+			return &routes[i]
+		}
+	}
+	return nil
+}
+
+//The address with its prefix, the class mask if there's no Subnet Mask.
+func leaseAddress(l dhcp4client.Lease) net.IPNet {
+	mask := l.SubnetMask
+	if mask == nil {
+		mask = l.FixedAddress.DefaultMask()
+	}
+	return net.IPNet{IP: l.FixedAddress.To4(), Mask: mask}
+}
+
+//Seconds left on the Lease, infinite if the Lease is.
+func leaseLifetime(l dhcp4client.Lease) uint32 {
+	if l.LeaseTime >= dhcp4client.InfiniteLeaseTime {
+		return infinityLifetime
+	}
+
+	remaining := time.Until(l.ExpiresAt()) / time.Second
+	if remaining < 1 {
+		remaining = 1
+	}
+	if remaining >= infinityLifetime {
+		remaining = infinityLifetime - 1
+	}
+	return uint32(remaining)
+}
+
+//https://tools.ietf.org/html/rfc3442#page-5 when there are Classless Static
+//Routes the Router option must be ignored.
+func leaseRoutes(l dhcp4client.Lease) []dhcp4client.Route {
+	if len(l.ClasslessRoutes) > 0 {
+		return l.ClasslessRoutes
+	}
+
+	if len(l.Routers) > 0 {
+		return []dhcp4client.Route{{
+			Destination: net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)},
+			Gateway:     l.Routers[0],
+		}}
+	}
+
+	return nil
+}
+
+//Something we added has already gone, e.g. the kernel removed the address
+//when it expired.
+func ignoreMissing(err error) error {
+	if errors.Is(err, unix.ESRCH) || errors.Is(err, unix.EADDRNOTAVAIL) || errors.Is(err, unix.ENODEV) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,424 @@
+package netconf_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+	"github.com/d2g/dhcp4client/netconf"
+)
+
+//198.18.0.0/15 is reserved for testing.
+var (
+	testAddress = "198.18.0.10/24"
+	testRoute   = net.IPNet{IP: net.IPv4(198, 19, 0, 0).To4(), Mask: net.CIDRMask(16, 32)}
+	otherRoute  = net.IPNet{IP: net.IPv4(198, 18, 128, 0).To4(), Mask: net.CIDRMask(17, 32)}
+)
+
+//A Lease for 198.18.0.10/24 from 198.18.0.1.
+func testLease(test *testing.T, options ...dhcp4.Option) dhcp4client.Lease {
+	request := dhcp4.NewPacket(dhcp4.BootRequest)
+	options = append(options, dhcp4.Option{Code: dhcp4.OptionSubnetMask, Value: []byte{255, 255, 255, 0}})
+	acknowledgement := dhcp4.ReplyPacket(request, dhcp4.ACK, net.IPv4(198, 18, 0, 1).To4(), net.IPv4(198, 18, 0, 10), time.Hour, options)
+
+	lease, err := dhcp4client.NewLease(acknowledgement, time.Now())
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	return lease
+}
+
+//A Configurator for the loopback interface of a network namespace of the
+//test's own, so the host's interfaces are never changed. Skips the test if it
+//can't have one.
+func testConfigurator(test *testing.T) (*netconf.Configurator, *net.Interface) {
+	//The namespace belongs to this thread which, never being unlocked, exits
+	//with the test (after its Cleanup) taking the namespace with it.
+	runtime.LockOSThread()
+	if err := syscall.Unshare(syscall.CLONE_NEWNET); err != nil {
+		test.Skipf("Test Skipping as it needs its own network namespace:%v", err)
+	}
+
+	lo, err := net.InterfaceByName("lo")
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	//Routes via the test's gateways need the link up.
+	setUp(test, lo.Index)
+
+	c, err := netconf.NewConfigurator(lo.Index)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	test.Cleanup(func() {
+		c.Remove()
+		c.Close()
+	})
+
+	return c, lo
+}
+
+//Bring the interface up over rtnetlink.
+func setUp(test *testing.T, ifindex int) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	defer syscall.Close(fd)
+
+	request := make([]byte, syscall.NLMSG_HDRLEN+syscall.SizeofIfInfomsg)
+	binary.NativeEndian.PutUint32(request[0:4], uint32(len(request)))
+	binary.NativeEndian.PutUint16(request[4:6], syscall.RTM_NEWLINK)
+	binary.NativeEndian.PutUint16(request[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_ACK)
+	binary.NativeEndian.PutUint32(request[8:12], 1)
+	ifinfo := request[syscall.NLMSG_HDRLEN:]
+	ifinfo[0] = syscall.AF_UNSPEC
+	binary.NativeEndian.PutUint32(ifinfo[4:8], uint32(ifindex))
+	binary.NativeEndian.PutUint32(ifinfo[8:12], syscall.IFF_UP)
+	binary.NativeEndian.PutUint32(ifinfo[12:16], syscall.IFF_UP)
+
+	if err := syscall.Sendto(fd, request, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	reply := make([]byte, syscall.Getpagesize())
+	n, _, err := syscall.Recvfrom(fd, reply, 0)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	messages, err := syscall.ParseNetlinkMessage(reply[:n])
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	for _, m := range messages {
+		if m.Header.Type == syscall.NLMSG_ERROR && len(m.Data) >= 4 {
+			if errno := -int32(binary.NativeEndian.Uint32(m.Data[0:4])); errno != 0 {
+				test.Fatalf("Error bringing up interface %d:%v\n", ifindex, syscall.Errno(errno))
+			}
+		}
+	}
+}
+
+//Apply the Lease skipping the test if we're not allowed to.
+func apply(test *testing.T, c *netconf.Configurator, lease dhcp4client.Lease) {
+	if err := c.Apply(lease); err != nil {
+		if errors.Is(err, syscall.EPERM) {
+			test.Skip("Test Skipping as it needs CAP_NET_ADMIN")
+		}
+		test.Fatalf("Error:%v\n", err)
+	}
+}
+
+//The gateway of the main table's route to destination, false if there isn't
+//one.
+func routeGateway(test *testing.T, destination net.IPNet) (net.IP, bool) {
+	rib, err := syscall.NetlinkRIB(syscall.RTM_GETROUTE, syscall.AF_INET)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	messages, err := syscall.ParseNetlinkMessage(rib)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	prefix, _ := destination.Mask.Size()
+	for _, m := range messages {
+		if m.Header.Type != syscall.RTM_NEWROUTE || len(m.Data) < syscall.SizeofRtMsg || int(m.Data[1]) != prefix {
+			continue
+		}
+
+		attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+		if err != nil {
+			test.Fatalf("Error:%v\n", err)
+		}
+
+		var dst, gateway net.IP
+		for _, a := range attrs {
+			switch a.Attr.Type {
+			case syscall.RTA_DST:
+				dst = net.IP(a.Value)
+			case syscall.RTA_GATEWAY:
+				gateway = net.IP(a.Value)
+			}
+		}
+		if dst.Equal(destination.IP) {
+			return gateway, true
+		}
+	}
+	return nil, false
+}
+
+//Does the interface have the address.
+func hasAddress(test *testing.T, ifindex int, address string) bool {
+	iface, err := net.InterfaceByIndex(ifindex)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	for _, a := range addrs {
+		if a.String() == address {
+			return true
+		}
+	}
+	return false
+}
+
+func mtu(test *testing.T, ifindex int) int {
+	iface, err := net.InterfaceByIndex(ifindex)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	return iface.MTU
+}
+
+func Test_Configurator(test *testing.T) {
+	c, lo := testConfigurator(test)
+
+	apply(test, c, testLease(test,
+		dhcp4.Option{Code: dhcp4.OptionInterfaceMTU, Value: []byte{5, 120}},
+		dhcp4.Option{Code: dhcp4.OptionClasslessRouteFormat, Value: []byte{16, 198, 19, 198, 18, 0, 1}},
+	))
+
+	if m := mtu(test, lo.Index); m != 1400 {
+		test.Errorf("MTU:%d, expected 1400", m)
+	}
+	if !hasAddress(test, lo.Index, testAddress) {
+		test.Error("Address wasn't added")
+	}
+	if _, ok := routeGateway(test, testRoute); !ok {
+		test.Error("Classless route wasn't added")
+	}
+
+	if err := c.Remove(); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	if m := mtu(test, lo.Index); m != lo.MTU {
+		test.Errorf("MTU:%d, expected it to be restored to %d", m, lo.MTU)
+	}
+	if hasAddress(test, lo.Index, testAddress) {
+		test.Error("Address wasn't removed")
+	}
+	if _, ok := routeGateway(test, testRoute); ok {
+		test.Error("Classless route wasn't removed")
+	}
+}
+
+//A renewal changing the gateway replaces the route, removes routes no longer
+//sent and restores the MTU when it's no longer sent.
+func Test_ConfiguratorRenew(test *testing.T) {
+	c, lo := testConfigurator(test)
+
+	apply(test, c, testLease(test,
+		dhcp4.Option{Code: dhcp4.OptionInterfaceMTU, Value: []byte{5, 120}},
+		dhcp4.Option{Code: dhcp4.OptionClasslessRouteFormat, Value: []byte{16, 198, 19, 198, 18, 0, 1, 17, 198, 18, 128, 198, 18, 0, 1}},
+	))
+	apply(test, c, testLease(test,
+		dhcp4.Option{Code: dhcp4.OptionClasslessRouteFormat, Value: []byte{16, 198, 19, 198, 18, 0, 2}},
+	))
+
+	if gateway, ok := routeGateway(test, testRoute); !ok || !gateway.Equal(net.IPv4(198, 18, 0, 2)) {
+		test.Errorf("Route gateway:%v, expected 198.18.0.2", gateway)
+	}
+	if _, ok := routeGateway(test, otherRoute); ok {
+		test.Error("Route no longer in the Lease wasn't removed")
+	}
+	if !hasAddress(test, lo.Index, testAddress) {
+		test.Error("Address was removed")
+	}
+	if m := mtu(test, lo.Index); m != lo.MTU {
+		test.Errorf("MTU:%d, expected it to be restored to %d", m, lo.MTU)
+	}
+}
+
+//A Lease with a route that can't be installed (the gateway isn't reachable)
+//leaves the interface as it was.
+func Test_ConfiguratorRollback(test *testing.T) {
+	c, lo := testConfigurator(test)
+
+	unreachable := testLease(test,
+		dhcp4.Option{Code: dhcp4.OptionInterfaceMTU, Value: []byte{5, 120}},
+		dhcp4.Option{Code: dhcp4.OptionClasslessRouteFormat, Value: []byte{16, 198, 19, 198, 18, 0, 2, 17, 198, 18, 128, 203, 0, 113, 1}},
+	)
+
+	if err := c.Apply(unreachable); err == nil {
+		test.Fatal("Expected an error installing a route via an unreachable gateway")
+	} else if errors.Is(err, syscall.EPERM) {
+		test.Skip("Test Skipping as it needs CAP_NET_ADMIN")
+	}
+
+	if hasAddress(test, lo.Index, testAddress) {
+		test.Error("Address wasn't removed")
+	}
+	if _, ok := routeGateway(test, testRoute); ok {
+		test.Error("Route wasn't removed")
+	}
+
+	apply(test, c, testLease(test,
+		dhcp4.Option{Code: dhcp4.OptionInterfaceMTU, Value: []byte{5, 100}},
+		dhcp4.Option{Code: dhcp4.OptionClasslessRouteFormat, Value: []byte{16, 198, 19, 198, 18, 0, 1}},
+	))
+
+	if err := c.Apply(unreachable); err == nil {
+		test.Fatal("Expected an error installing a route via an unreachable gateway")
+	}
+
+	if gateway, ok := routeGateway(test, testRoute); !ok || !gateway.Equal(net.IPv4(198, 18, 0, 1)) {
+		test.Errorf("Route gateway:%v, expected it to be restored to 198.18.0.1", gateway)
+	}
+	if !hasAddress(test, lo.Index, testAddress) {
+		test.Error("Address was removed")
+	}
+	if m := mtu(test, lo.Index); m != 1380 {
+		test.Errorf("MTU:%d, expected 1380", m)
+	}
+
+	if err := c.Remove(); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if m := mtu(test, lo.Index); m != lo.MTU {
+		test.Errorf("MTU:%d, expected it to be restored to %d", m, lo.MTU)
+	}
+}
+
+//A failed first Apply leaves an address and route the interface already had,
+//e.g. after a restart, rather than deleting them.
+func Test_ConfiguratorRollbackExisting(test *testing.T) {
+	c, lo := testConfigurator(test)
+
+	//Another Configurator adds them, as the process before a restart would.
+	before, err := netconf.NewConfigurator(lo.Index)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	defer before.Close()
+	apply(test, before, testLease(test,
+		dhcp4.Option{Code: dhcp4.OptionClasslessRouteFormat, Value: []byte{16, 198, 19, 198, 18, 0, 1}},
+	))
+
+	if err := c.Apply(testLease(test,
+		dhcp4.Option{Code: dhcp4.OptionClasslessRouteFormat, Value: []byte{16, 198, 19, 198, 18, 0, 2, 17, 198, 18, 128, 203, 0, 113, 1}},
+	)); err == nil {
+		test.Fatal("Expected an error installing a route via an unreachable gateway")
+	}
+
+	if !hasAddress(test, lo.Index, testAddress) {
+		test.Error("Address was removed")
+	}
+	if gateway, ok := routeGateway(test, testRoute); !ok || !gateway.Equal(net.IPv4(198, 18, 0, 1)) {
+		test.Errorf("Route gateway:%v, expected it to be restored to 198.18.0.1", gateway)
+	}
+
+	if err := before.Remove(); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+}
+
+//Removing a Lease puts back a route to the same destination that the
+//interface had before it.
+func Test_ConfiguratorRemoveRestoresRoute(test *testing.T) {
+	c, lo := testConfigurator(test)
+
+	//Another Configurator, with an address of its own, adds the route first.
+	before, err := netconf.NewConfigurator(lo.Index)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	defer before.Close()
+
+	acknowledgement := dhcp4.ReplyPacket(dhcp4.NewPacket(dhcp4.BootRequest), dhcp4.ACK, net.IPv4(198, 18, 0, 1).To4(), net.IPv4(198, 18, 1, 10), time.Hour, []dhcp4.Option{
+		{Code: dhcp4.OptionSubnetMask, Value: []byte{255, 255, 0, 0}},
+		{Code: dhcp4.OptionClasslessRouteFormat, Value: []byte{16, 198, 19, 198, 18, 0, 1}},
+	})
+	lease, err := dhcp4client.NewLease(acknowledgement, time.Now())
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	apply(test, before, lease)
+	defer before.Remove()
+
+	apply(test, c, testLease(test,
+		dhcp4.Option{Code: dhcp4.OptionClasslessRouteFormat, Value: []byte{16, 198, 19, 198, 18, 0, 2}},
+	))
+	if gateway, ok := routeGateway(test, testRoute); !ok || !gateway.Equal(net.IPv4(198, 18, 0, 2)) {
+		test.Errorf("Route gateway:%v, expected 198.18.0.2", gateway)
+	}
+
+	if err := c.Remove(); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if gateway, ok := routeGateway(test, testRoute); !ok || !gateway.Equal(net.IPv4(198, 18, 0, 1)) {
+		test.Errorf("Route gateway:%v, expected it to be restored to 198.18.0.1", gateway)
+	}
+}
+
+//Records the DNS configuration, failing if err is set.
+type testDNS struct {
+	configs map[string]netconf.DNSConfig
+	sets    int
+	err     error
+}
+
+func (d *testDNS) SetDNS(iface string, config netconf.DNSConfig) error {
+	if d.err != nil {
+		return d.err
+	}
+	d.sets++
+	d.configs[iface] = config
+	return nil
+}
+
+func (d *testDNS) RemoveDNS(iface string) error {
+	delete(d.configs, iface)
+	return nil
+}
+
+//DNS is only configured when it changes and a failure configuring it undoes
+//the rest of the Lease.
+func Test_ConfiguratorDNS(test *testing.T) {
+	dns := &testDNS{configs: make(map[string]netconf.DNSConfig)}
+	c, lo := testConfigurator(test)
+	if err := c.SetOption(netconf.DNS(dns)); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	lease := testLease(test,
+		dhcp4.Option{Code: dhcp4.OptionDomainNameServer, Value: []byte{198, 18, 0, 1}},
+		dhcp4.Option{Code: dhcp4.OptionDomainName, Value: []byte("example.com")},
+	)
+	apply(test, c, lease)
+	apply(test, c, lease)
+
+	if config, ok := dns.configs[lo.Name]; !ok || config.Domain != "example.com" || len(config.Nameservers) != 1 {
+		test.Errorf("DNS:%v", dns.configs)
+	}
+	if dns.sets != 1 {
+		test.Errorf("DNS was set %d times, expected once", dns.sets)
+	}
+
+	if err := c.Remove(); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if len(dns.configs) != 0 {
+		test.Errorf("DNS wasn't removed:%v", dns.configs)
+	}
+
+	dns.err = errors.New("resolver manager unavailable")
+	if err := c.Apply(lease); !errors.Is(err, dns.err) {
+		test.Fatalf("Error:%v, expected %v", err, dns.err)
+	}
+	if hasAddress(test, lo.Index, testAddress) {
+		test.Error("Address wasn't removed after DNS failed")
+	}
+}
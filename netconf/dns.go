@@ -0,0 +1,55 @@
+package netconf
+
+import (
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/d2g/dhcp4client"
+)
+
+//The resolver configuration from a Lease.
+type DNSConfig struct {
+	Nameservers []net.IP //Option 6
+	Domain      string   //Option 15
+	Search      []string //Option 119
+}
+
+//The resolver configuration from a Lease's (or an INFORM's) Configuration.
+func NewDNSConfig(c dhcp4client.Configuration) DNSConfig {
+	return DNSConfig{
+		Nameservers: c.DNSServers,
+		//Some servers include the terminating NUL or a trailing ".".
+		Domain: strings.TrimSuffix(strings.TrimRight(c.DomainName, "\x00"), "."),
+		Search: c.DomainSearch,
+	}
+}
+
+//The search domains, the Domain if the server didn't send a Domain Search
+//list.
+func (d DNSConfig) SearchDomains() []string {
+	if len(d.Search) == 0 && d.Domain != "" {
+		return []string{d.Domain}
+	}
+	return d.Search
+}
+
+func (d DNSConfig) equal(o DNSConfig) bool {
+	return d.Domain == o.Domain &&
+		slices.Equal(d.Search, o.Search) &&
+		slices.EqualFunc(d.Nameservers, o.Nameservers, net.IP.Equal)
+}
+
+func (d DNSConfig) empty() bool {
+	return len(d.Nameservers) == 0 && d.Domain == "" && len(d.Search) == 0
+}
+
+//Configures the resolver for an interface, e.g. by writing resolv.conf or
+//handing it to a resolver manager.
+type DNSConfigurator interface {
+	//Set the interface's configuration, replacing what was set before.
+	SetDNS(iface string, config DNSConfig) error
+	//Remove the interface's configuration. Removing configuration that
+	//isn't set isn't an error.
+	RemoveDNS(iface string) error
+}
@@ -0,0 +1,6 @@
+//Package netconf applies DHCP Leases to Linux network interfaces over
+//rtnetlink: the address, routes, MTU and DNS, removing them again when the
+//Lease is released or expires.
+//The resolv.conf writers (ResolvConf and ResolvConfDir) can be used on their
+//own on any platform.
+package netconf
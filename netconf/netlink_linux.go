@@ -0,0 +1,319 @@
+package netconf
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	//Lifetime of an address which never expires.
+	infinityLifetime = 0xFFFFFFFF
+)
+
+//A rtnetlink socket.
+type netlink struct {
+	fd  int
+	seq uint32
+}
+
+func dialNetlink() (*netlink, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_ROUTE)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+
+	return &netlink{fd: fd}, nil
+}
+
+func (n *netlink) Close() error {
+	return unix.Close(n.fd)
+}
+
+//A request, the netlink header is added when it's sent.
+type request struct {
+	typ   uint16
+	flags uint16
+	data  []byte
+}
+
+//Append an attribute (struct rtattr) to the request.
+func (r *request) attr(typ uint16, value []byte) {
+	attr := make([]byte, rtaAlign(unix.SizeofRtAttr+len(value)))
+	binary.NativeEndian.PutUint16(attr[0:2], uint16(unix.SizeofRtAttr+len(value)))
+	binary.NativeEndian.PutUint16(attr[2:4], typ)
+	copy(attr[unix.SizeofRtAttr:], value)
+	r.data = append(r.data, attr...)
+}
+
+func (r *request) attrUint32(typ uint16, value uint32) {
+	b := make([]byte, 4)
+	binary.NativeEndian.PutUint32(b, value)
+	r.attr(typ, b)
+}
+
+func rtaAlign(n int) int {
+	return (n + unix.RTA_ALIGNTO - 1) &^ (unix.RTA_ALIGNTO - 1)
+}
+
+//Send the request and wait for the kernel to acknowledge it.
+func (n *netlink) execute(r request) error {
+	n.seq++
+
+	b := make([]byte, unix.NLMSG_HDRLEN+len(r.data))
+	binary.NativeEndian.PutUint32(b[0:4], uint32(len(b)))
+	binary.NativeEndian.PutUint16(b[4:6], r.typ)
+	binary.NativeEndian.PutUint16(b[6:8], r.flags|unix.NLM_F_REQUEST|unix.NLM_F_ACK)
+	binary.NativeEndian.PutUint32(b[8:12], n.seq)
+	copy(b[unix.NLMSG_HDRLEN:], r.data)
+
+	if err := unix.Sendto(n.fd, b, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return err
+	}
+
+	buffer := make([]byte, 8192)
+	for {
+		length, _, err := unix.Recvfrom(n.fd, buffer, 0)
+		if err != nil {
+			return err
+		}
+
+		messages, err := syscall.ParseNetlinkMessage(buffer[:length])
+		if err != nil {
+			return err
+		}
+
+		for _, m := range messages {
+			if m.Header.Seq != n.seq || m.Header.Type != unix.NLMSG_ERROR {
+				continue
+			}
+			if len(m.Data) < 4 {
+				return unix.EINVAL
+			}
+
+			//struct nlmsgerr, an error of 0 acknowledges the request.
+			if errno := int32(binary.NativeEndian.Uint32(m.Data[0:4])); errno != 0 {
+				return syscall.Errno(-errno)
+			}
+			return nil
+		}
+	}
+}
+
+//Add (or update) or delete an IPv4 address (RTM_NEWADDR/RTM_DELADDR).
+func addressRequest(typ uint16, ifindex int, address net.IPNet, valid uint32, preferred uint32) request {
+	prefix, _ := address.Mask.Size()
+
+	//struct ifaddrmsg
+	ifaddrmsg := make([]byte, unix.SizeofIfAddrmsg)
+	ifaddrmsg[0] = unix.AF_INET
+	ifaddrmsg[1] = byte(prefix)
+	ifaddrmsg[3] = unix.RT_SCOPE_UNIVERSE
+	binary.NativeEndian.PutUint32(ifaddrmsg[4:8], uint32(ifindex))
+
+	r := request{typ: typ, data: ifaddrmsg}
+	if typ == unix.RTM_NEWADDR {
+		r.flags = unix.NLM_F_CREATE | unix.NLM_F_REPLACE
+	}
+
+	r.attr(unix.IFA_LOCAL, address.IP.To4())
+	r.attr(unix.IFA_ADDRESS, address.IP.To4())
+
+	if typ == unix.RTM_NEWADDR {
+		broadcast := make(net.IP, net.IPv4len)
+		for i := range broadcast {
+			broadcast[i] = address.IP.To4()[i] | ^address.Mask[i]
+		}
+		r.attr(unix.IFA_BROADCAST, broadcast)
+
+		//struct ifa_cacheinfo
+		cacheinfo := make([]byte, unix.SizeofIfaCacheinfo)
+		binary.NativeEndian.PutUint32(cacheinfo[0:4], preferred)
+		binary.NativeEndian.PutUint32(cacheinfo[4:8], valid)
+		r.attr(unix.IFA_CACHEINFO, cacheinfo)
+	}
+
+	return r
+}
+
+//Add (or replace) or delete an IPv4 route in the main table
+//(RTM_NEWROUTE/RTM_DELROUTE).
+func routeRequest(typ uint16, ifindex int, destination net.IPNet, gateway net.IP, source net.IP) request {
+	prefix, _ := destination.Mask.Size()
+	onLink := gateway == nil || gateway.IsUnspecified()
+
+	//struct rtmsg
+	rtmsg := make([]byte, unix.SizeofRtMsg)
+	rtmsg[0] = unix.AF_INET
+	rtmsg[1] = byte(prefix)
+	rtmsg[4] = unix.RT_TABLE_MAIN
+	rtmsg[5] = unix.RTPROT_DHCP
+	rtmsg[6] = unix.RT_SCOPE_UNIVERSE
+	if onLink {
+		rtmsg[6] = unix.RT_SCOPE_LINK
+	}
+	rtmsg[7] = unix.RTN_UNICAST
+
+	r := request{typ: typ, data: rtmsg}
+	if typ == unix.RTM_NEWROUTE {
+		r.flags = unix.NLM_F_CREATE | unix.NLM_F_REPLACE
+	}
+
+	if prefix > 0 {
+		r.attr(unix.RTA_DST, destination.IP.To4())
+	}
+	if !onLink {
+		r.attr(unix.RTA_GATEWAY, gateway.To4())
+	}
+	if source != nil {
+		r.attr(unix.RTA_PREFSRC, source.To4())
+	}
+	r.attrUint32(unix.RTA_OIF, uint32(ifindex))
+
+	return r
+}
+
+//Set the MTU of an interface (RTM_NEWLINK on an existing link).
+func mtuRequest(ifindex int, mtu int) request {
+	//struct ifinfomsg
+	ifinfomsg := make([]byte, unix.SizeofIfInfomsg)
+	ifinfomsg[0] = unix.AF_UNSPEC
+	binary.NativeEndian.PutUint32(ifinfomsg[4:8], uint32(ifindex))
+
+	r := request{typ: unix.RTM_NEWLINK, data: ifinfomsg}
+	r.attrUint32(unix.IFLA_MTU, uint32(mtu))
+	return r
+}
+
+//An IPv4 address the interface already has, with its remaining lifetimes.
+type kernelAddress struct {
+	valid     uint32
+	preferred uint32
+}
+
+//The address if the interface already has it (RTM_GETADDR).
+func lookupAddress(ifindex int, address net.IPNet) (kernelAddress, bool, error) {
+	rib, err := syscall.NetlinkRIB(unix.RTM_GETADDR, unix.AF_INET)
+	if err != nil {
+		return kernelAddress{}, false, err
+	}
+
+	messages, err := syscall.ParseNetlinkMessage(rib)
+	if err != nil {
+		return kernelAddress{}, false, err
+	}
+
+	prefix, _ := address.Mask.Size()
+	for _, m := range messages {
+		//struct ifaddrmsg
+		if m.Header.Type != unix.RTM_NEWADDR || len(m.Data) < unix.SizeofIfAddrmsg {
+			continue
+		}
+		if int(m.Data[1]) != prefix || int(binary.NativeEndian.Uint32(m.Data[4:8])) != ifindex {
+			continue
+		}
+
+		attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+		if err != nil {
+			return kernelAddress{}, false, err
+		}
+
+		var local net.IP
+		a := kernelAddress{valid: infinityLifetime, preferred: infinityLifetime}
+		for _, attr := range attrs {
+			switch attr.Attr.Type {
+			case unix.IFA_LOCAL:
+				local = net.IP(attr.Value)
+			case unix.IFA_CACHEINFO:
+				//struct ifa_cacheinfo
+				if len(attr.Value) >= 8 {
+					a.preferred = binary.NativeEndian.Uint32(attr.Value[0:4])
+					a.valid = binary.NativeEndian.Uint32(attr.Value[4:8])
+				}
+			}
+		}
+		if local.Equal(address.IP) {
+			return a, true, nil
+		}
+	}
+	return kernelAddress{}, false, nil
+}
+
+//A route already in the main table.
+type kernelRoute struct {
+	ifindex int
+	gateway net.IP
+	source  net.IP
+}
+
+//Is it the route via gateway (on-link if it's unspecified) from the interface.
+func (r kernelRoute) equal(ifindex int, gateway net.IP) bool {
+	onLink := gateway == nil || gateway.IsUnspecified()
+	if onLink || r.gateway == nil {
+		return r.ifindex == ifindex && onLink && r.gateway == nil
+	}
+	return r.ifindex == ifindex && r.gateway.Equal(gateway)
+}
+
+//The main table's route to destination (RTM_GETROUTE) if there's one a route
+//we add would replace, i.e. without a metric.
+func lookupRoute(destination net.IPNet) (kernelRoute, bool, error) {
+	rib, err := syscall.NetlinkRIB(unix.RTM_GETROUTE, unix.AF_INET)
+	if err != nil {
+		return kernelRoute{}, false, err
+	}
+
+	messages, err := syscall.ParseNetlinkMessage(rib)
+	if err != nil {
+		return kernelRoute{}, false, err
+	}
+
+	prefix, _ := destination.Mask.Size()
+	for _, m := range messages {
+		//struct rtmsg
+		if m.Header.Type != unix.RTM_NEWROUTE || len(m.Data) < unix.SizeofRtMsg {
+			continue
+		}
+		if int(m.Data[1]) != prefix || m.Data[4] != unix.RT_TABLE_MAIN {
+			continue
+		}
+
+		attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+		if err != nil {
+			return kernelRoute{}, false, err
+		}
+
+		dst := net.IPv4zero
+		var r kernelRoute
+		var priority uint32
+		for _, attr := range attrs {
+			switch attr.Attr.Type {
+			case unix.RTA_DST:
+				dst = net.IP(attr.Value)
+			case unix.RTA_GATEWAY:
+				r.gateway = net.IP(attr.Value)
+			case unix.RTA_PREFSRC:
+				r.source = net.IP(attr.Value)
+			case unix.RTA_OIF:
+				if len(attr.Value) >= 4 {
+					r.ifindex = int(binary.NativeEndian.Uint32(attr.Value))
+				}
+			case unix.RTA_PRIORITY:
+				if len(attr.Value) >= 4 {
+					priority = binary.NativeEndian.Uint32(attr.Value)
+				}
+			}
+		}
+		if priority == 0 && dst.Equal(destination.IP) {
+			return r, true, nil
+		}
+	}
+	return kernelRoute{}, false, nil
+}
@@ -0,0 +1,149 @@
+package netconf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/d2g/dhcp4client/internal/atomicfile"
+)
+
+//A DNSConfigurator writing a resolv.conf file with the configuration from
+//every interface. The file is replaced atomically.
+type ResolvConf struct {
+	mu         sync.Mutex
+	path       string
+	interfaces map[string]DNSConfig
+}
+
+func NewResolvConf(path string) *ResolvConf {
+	return &ResolvConf{
+		path:       path,
+		interfaces: make(map[string]DNSConfig),
+	}
+}
+
+func (r *ResolvConf) SetDNS(iface string, config DNSConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.interfaces[iface]
+	r.interfaces[iface] = config
+	if err := r.write(); err != nil {
+		if ok {
+			r.interfaces[iface] = previous
+		} else {
+			delete(r.interfaces, iface)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *ResolvConf) RemoveDNS(iface string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.interfaces[iface]
+	if !ok {
+		return nil
+	}
+
+	delete(r.interfaces, iface)
+	if err := r.write(); err != nil {
+		r.interfaces[iface] = previous
+		return err
+	}
+	return nil
+}
+
+//Write the interfaces' configuration, in name order, to the file.
+func (r *ResolvConf) write() error {
+	names := make([]string, 0, len(r.interfaces))
+	for name := range r.interfaces {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	configs := make([]DNSConfig, 0, len(names))
+	for _, name := range names {
+		configs = append(configs, r.interfaces[name])
+	}
+
+	return atomicfile.WriteFile(r.path, formatResolvConf(strings.Join(names, ", "), configs...), 0644)
+}
+
+//A DNSConfigurator writing each interface's configuration to its own
+//resolv.conf format fragment, named after the interface, in a directory, for
+//a resolver manager (e.g. resolvconf(8)) to merge.
+//Fragments are replaced atomically.
+type ResolvConfDir struct {
+	dir string
+}
+
+func NewResolvConfDir(dir string) *ResolvConfDir {
+	return &ResolvConfDir{
+		dir: dir,
+	}
+}
+
+func (r *ResolvConfDir) SetDNS(iface string, config DNSConfig) error {
+	path, err := r.fragment(iface)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, formatResolvConf(iface, config), 0644)
+}
+
+func (r *ResolvConfDir) RemoveDNS(iface string) error {
+	path, err := r.fragment(iface)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (r *ResolvConfDir) fragment(iface string) (string, error) {
+	if iface == "" || iface == "." || iface == ".." || strings.ContainsRune(iface, filepath.Separator) {
+		return "", fmt.Errorf("invalid interface name %q", iface)
+	}
+	return filepath.Join(r.dir, iface), nil
+}
+
+//Format configurations as a resolv.conf, search domains and nameservers in
+//order without duplicates.
+func formatResolvConf(source string, configs ...DNSConfig) []byte {
+	var search []string
+	var nameservers []string
+
+	for _, config := range configs {
+		for _, domain := range config.SearchDomains() {
+			if !slices.Contains(search, domain) {
+				search = append(search, domain)
+			}
+		}
+		for _, nameserver := range config.Nameservers {
+			if !slices.Contains(nameservers, nameserver.String()) {
+				nameservers = append(nameservers, nameserver.String())
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Generated by dhcp4client for %s\n", source)
+	if len(search) > 0 {
+		fmt.Fprintf(&b, "search %s\n", strings.Join(search, " "))
+	}
+	for _, nameserver := range nameservers {
+		fmt.Fprintf(&b, "nameserver %s\n", nameserver)
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,104 @@
+package netconf_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/d2g/dhcp4client/netconf"
+)
+
+func readFile(test *testing.T, path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	return string(b)
+}
+
+func Test_ResolvConf(test *testing.T) {
+	path := filepath.Join(test.TempDir(), "resolv.conf")
+	r := netconf.NewResolvConf(path)
+
+	err := r.SetDNS("eth1", netconf.DNSConfig{
+		Nameservers: []net.IP{net.IPv4(192, 168, 2, 1), net.IPv4(8, 8, 8, 8)},
+		Domain:      "example.net",
+	})
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	err = r.SetDNS("eth0", netconf.DNSConfig{
+		Nameservers: []net.IP{net.IPv4(8, 8, 8, 8)},
+		Domain:      "example.com",
+		Search:      []string{"eng.example.com", "example.com"},
+	})
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	expected := "# Generated by dhcp4client for eth0, eth1\n" +
+		"search eng.example.com example.com example.net\n" +
+		"nameserver 8.8.8.8\n" +
+		"nameserver 192.168.2.1\n"
+	if s := readFile(test, path); s != expected {
+		test.Errorf("resolv.conf:\n%s\nexpected:\n%s", s, expected)
+	}
+
+	if err := r.RemoveDNS("eth0"); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	expected = "# Generated by dhcp4client for eth1\n" +
+		"search example.net\n" +
+		"nameserver 192.168.2.1\n" +
+		"nameserver 8.8.8.8\n"
+	if s := readFile(test, path); s != expected {
+		test.Errorf("resolv.conf:\n%s\nexpected:\n%s", s, expected)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(path), ".resolv.conf.tmp*"))
+	if err != nil || len(matches) > 0 {
+		test.Errorf("Temporary files left behind:%v %v", matches, err)
+	}
+}
+
+func Test_ResolvConfDir(test *testing.T) {
+	dir := test.TempDir()
+	r := netconf.NewResolvConfDir(dir)
+
+	err := r.SetDNS("eth0", netconf.DNSConfig{
+		Nameservers: []net.IP{net.IPv4(192, 168, 1, 1)},
+		Search:      []string{"example.com"},
+	})
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	expected := "# Generated by dhcp4client for eth0\n" +
+		"search example.com\n" +
+		"nameserver 192.168.1.1\n"
+	if s := readFile(test, filepath.Join(dir, "eth0")); s != expected {
+		test.Errorf("Fragment:\n%s\nexpected:\n%s", s, expected)
+	}
+
+	//Nothing else a resolver manager would merge.
+	if files, err := os.ReadDir(dir); err != nil || len(files) != 1 {
+		test.Errorf("Files:%v %v, expected only the fragment", files, err)
+	}
+
+	if err := r.RemoveDNS("eth0"); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "eth0")); !os.IsNotExist(err) {
+		test.Errorf("Fragment wasn't removed:%v", err)
+	}
+	if err := r.RemoveDNS("eth0"); err != nil {
+		test.Errorf("Error removing a missing fragment:%v", err)
+	}
+
+	if err := r.SetDNS("../eth0", netconf.DNSConfig{}); err == nil {
+		test.Error("Expected an error for an interface name outside the directory")
+	}
+}
@@ -0,0 +1,41 @@
+package netconf
+
+import (
+	"fmt"
+)
+
+//The changes made to an interface so far, so they can all be undone if a later
+//one fails.
+type transaction struct {
+	undo []func() error
+}
+
+//Make a change, remembering how to undo it if it succeeds.
+func (t *transaction) do(change func() error, undo func() error) error {
+	if err := change(); err != nil {
+		return err
+	}
+	t.undo = append(t.undo, undo)
+	return nil
+}
+
+//Undo the changes, newest first. Carries on past errors, returning the first.
+func (t *transaction) rollback() error {
+	var first error
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		if err := t.undo[i](); err != nil && first == nil {
+			first = err
+		}
+	}
+	t.undo = nil
+	return first
+}
+
+//The error for a change that failed, including any failure undoing the
+//changes before it.
+func rollbackError(err error, rollback error) error {
+	if rollback != nil {
+		return fmt.Errorf("%w (rollback failed: %w)", err, rollback)
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package dhcp4client
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/d2g/dhcp4"
+)
+
+//Picks the Offer to Request from all those received.
+type OfferSelector interface {
+	//Select one of the offers, there's always at least one.
+	Select(offers []dhcp4.Packet) dhcp4.Packet
+}
+
+//Adapts a function to an OfferSelector.
+type OfferSelectorFunc func(offers []dhcp4.Packet) dhcp4.Packet
+
+func (f OfferSelectorFunc) Select(offers []dhcp4.Packet) dhcp4.Packet {
+	return f(offers)
+}
+
+//Select the first Offer received.
+func FirstOffer() OfferSelector {
+	return OfferSelectorFunc(func(offers []dhcp4.Packet) dhcp4.Packet {
+		return offers[0]
+	})
+}
+
+//Select the first Offer from the server, or the first Offer if it didn't make one.
+func PreferServer(server net.IP) OfferSelector {
+	return OfferSelectorFunc(func(offers []dhcp4.Packet) dhcp4.Packet {
+		for _, offer := range offers {
+			if net.IP(ParseReplyOptions(offer)[dhcp4.OptionServerIdentifier]).Equal(server) {
+				return offer
+			}
+		}
+		return offers[0]
+	})
+}
+
+//Select the first Offer of the address (i.e. one we previously held), or the
+//first Offer if it wasn't offered.
+func PreferAddress(ip net.IP) OfferSelector {
+	return OfferSelectorFunc(func(offers []dhcp4.Packet) dhcp4.Packet {
+		for _, offer := range offers {
+			if offer.YIAddr().Equal(ip) {
+				return offer
+			}
+		}
+		return offers[0]
+	})
+}
+
+//Select the Offer with the longest lease time, the first received on a tie.
+func LongestLease() OfferSelector {
+	return OfferSelectorFunc(func(offers []dhcp4.Packet) dhcp4.Packet {
+		selected := offers[0]
+		longest, _ := optionDuration(ParseReplyOptions(selected), dhcp4.OptionIPAddressLeaseTime, 0)
+
+		for _, offer := range offers[1:] {
+			leaseTime, err := optionDuration(ParseReplyOptions(offer), dhcp4.OptionIPAddressLeaseTime, 0)
+			if err == nil && leaseTime > longest {
+				selected, longest = offer, leaseTime
+			}
+		}
+		return selected
+	})
+}
+
+//Retreive Offers...
+//Wait for the first offer for a specific Discovery Packet then gather all the
+//Offers received within the window.
+func (c *Client) GetOffers(discoverPacket *dhcp4.Packet, window time.Duration) ([]dhcp4.Packet, error) {
+	return c.GetOffersContext(context.Background(), discoverPacket, window)
+}
+
+//GetOffers, giving up as soon as the context is done.
+func (c *Client) GetOffersContext(ctx context.Context, discoverPacket *dhcp4.Packet, window time.Duration) ([]dhcp4.Packet, error) {
+	return c.getOffers(ctx, discoverPacket, c.timeout, window)
+}
+
+func (c *Client) getOffers(ctx context.Context, discoverPacket *dhcp4.Packet, timeout time.Duration, window time.Duration) ([]dhcp4.Packet, error) {
+	offerPacket, err := c.getOffer(ctx, discoverPacket, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	offers := []dhcp4.Packet{offerPacket}
+	end := time.Now().Add(window)
+
+	for remaining := window; remaining > 0; remaining = time.Until(end) {
+		offerPacket, err := c.getOffer(ctx, discoverPacket, remaining)
+		if err != nil {
+			if isTimeout(err) && ctx.Err() == nil {
+				break
+			}
+			return nil, err
+		}
+		offers = append(offers, offerPacket)
+	}
+
+	return offers, nil
+}
+
+//Wait for Offers and pick one using the Clients OfferSelector.
+func (c *Client) getSelectedOffer(ctx context.Context, discoverPacket *dhcp4.Packet, timeout time.Duration) (dhcp4.Packet, error) {
+	offers, err := c.getOffers(ctx, discoverPacket, timeout, c.offerWindow)
+	if err != nil {
+		return dhcp4.Packet{}, err
+	}
+	return c.offerSelector.Select(offers), nil
+}
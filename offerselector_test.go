@@ -0,0 +1,79 @@
+package dhcp4client_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+var (
+	serverA = net.IPv4(192, 168, 1, 1)
+	serverB = net.IPv4(192, 168, 1, 2)
+)
+
+func testOffers() []dhcp4.Packet {
+	discover := dhcp4.NewPacket(dhcp4.BootRequest)
+	return []dhcp4.Packet{
+		dhcp4.ReplyPacket(discover, dhcp4.Offer, serverA, net.IPv4(192, 168, 1, 100), time.Hour, nil),
+		dhcp4.ReplyPacket(discover, dhcp4.Offer, serverB, net.IPv4(192, 168, 1, 101), time.Hour*2, nil),
+	}
+}
+
+func Test_OfferSelectors(test *testing.T) {
+	offers := testOffers()
+
+	selectors := []struct {
+		name     string
+		selector dhcp4client.OfferSelector
+		expected net.IP
+	}{
+		{"FirstOffer", dhcp4client.FirstOffer(), net.IPv4(192, 168, 1, 100)},
+		{"PreferServer", dhcp4client.PreferServer(serverB), net.IPv4(192, 168, 1, 101)},
+		{"PreferServer (Missing)", dhcp4client.PreferServer(net.IPv4(10, 0, 0, 1)), net.IPv4(192, 168, 1, 100)},
+		{"PreferAddress", dhcp4client.PreferAddress(net.IPv4(192, 168, 1, 101)), net.IPv4(192, 168, 1, 101)},
+		{"LongestLease", dhcp4client.LongestLease(), net.IPv4(192, 168, 1, 101)},
+	}
+
+	for _, s := range selectors {
+		if selected := s.selector.Select(offers).YIAddr(); !selected.Equal(s.expected) {
+			test.Errorf("%s selected %v, expected %v", s.name, selected, s.expected)
+		}
+	}
+}
+
+func Test_RequestSelectsOffer(test *testing.T) {
+	server := newTestServer()
+	server.Reply = func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+		if dhcp4.MessageType(request.ParseOptions()[dhcp4.OptionDHCPMessageType][0]) != dhcp4.Discover {
+			//Acknowledge as the server that was Requested from.
+			return []dhcp4.Packet{dhcp4.ReplyPacket(request, dhcp4.ACK, request.ParseOptions()[dhcp4.OptionServerIdentifier], request.ParseOptions()[dhcp4.OptionRequestedIPAddress], time.Hour, nil)}
+		}
+
+		offers := testOffers()
+		for _, offer := range offers {
+			offer.SetXId(request.XId())
+			offer.SetCHAddr(request.CHAddr())
+		}
+		return offers
+	}
+
+	c := newTestClient(test, server)
+	c.SetOption(dhcp4client.SelectOffer(time.Millisecond*50, dhcp4client.PreferServer(serverB)))
+
+	success, _, err := c.Request()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	sent := server.SentPackets()
+	requestOptions := sent[len(sent)-1].ParseOptions()
+	if !net.IP(requestOptions[dhcp4.OptionServerIdentifier]).Equal(serverB) {
+		test.Errorf("Requested from %v, expected %v", net.IP(requestOptions[dhcp4.OptionServerIdentifier]), serverB)
+	}
+	if !net.IP(requestOptions[dhcp4.OptionRequestedIPAddress]).Equal(net.IPv4(192, 168, 1, 101)) {
+		test.Errorf("Requested %v, expected %v", net.IP(requestOptions[dhcp4.OptionRequestedIPAddress]), net.IPv4(192, 168, 1, 101))
+	}
+}
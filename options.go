@@ -0,0 +1,12 @@
+package dhcp4client
+
+import (
+	"github.com/d2g/dhcp4"
+)
+
+//Options not (yet) defined by github.com/d2g/dhcp4.
+const (
+	OptionRapidCommit  dhcp4.OptionCode = 80  //RFC 4039
+	OptionClientFQDN   dhcp4.OptionCode = 81  //RFC 4702
+	OptionDomainSearch dhcp4.OptionCode = 119 //RFC 3397
+)
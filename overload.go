@@ -0,0 +1,67 @@
+package dhcp4client
+
+import (
+	"github.com/d2g/dhcp4"
+)
+
+//Option Overload (option 52) values https://tools.ietf.org/html/rfc2132#section-9.3
+const (
+	overloadFile  = 1
+	overloadSName = 2
+)
+
+const (
+	sNameStart   = 44
+	fileStart    = 108
+	optionsStart = 240
+)
+
+//Parse the options in a reply from a server.
+//Unlike Packet.ParseOptions this follows Option Overload (option 52) into the
+//file and sname fields and joins options split into several parts (RFC 3396).
+func ParseReplyOptions(p dhcp4.Packet) dhcp4.Options {
+	options := make(dhcp4.Options, 10)
+	if len(p) < optionsStart {
+		return options
+	}
+
+	parseOptionsInto(options, p[optionsStart:])
+
+	//https://tools.ietf.org/html/rfc2131#section-4.1 the options field is
+	//read first, then file and then sname.
+	if overload := options[dhcp4.OptionOverload]; len(overload) == 1 {
+		if overload[0]&overloadFile != 0 {
+			parseOptionsInto(options, p[fileStart:optionsStart-4])
+		}
+		if overload[0]&overloadSName != 0 {
+			parseOptionsInto(options, p[sNameStart:fileStart])
+		}
+	}
+
+	return options
+}
+
+//Parse the options in b adding them to options, concatenating any which
+//appear more than once.
+func parseOptionsInto(options dhcp4.Options, b []byte) {
+	for len(b) >= 1 && dhcp4.OptionCode(b[0]) != dhcp4.End {
+		if dhcp4.OptionCode(b[0]) == dhcp4.Pad {
+			b = b[1:]
+			continue
+		}
+
+		if len(b) < 2 || len(b) < 2+int(b[1]) {
+			return
+		}
+
+		code := dhcp4.OptionCode(b[0])
+		value := b[2 : 2+int(b[1])]
+		if existing, ok := options[code]; ok {
+			options[code] = append(existing, value...)
+		} else {
+			options[code] = append([]byte{}, value...)
+		}
+
+		b = b[2+int(b[1]):]
+	}
+}
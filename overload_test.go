@@ -0,0 +1,71 @@
+package dhcp4client_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+func Test_OptionOverload(test *testing.T) {
+	acknowledgement := testAcknowledgement([]dhcp4.Option{
+		{Code: dhcp4.OptionOverload, Value: []byte{3}},
+		{Code: dhcp4.OptionDomainName, Value: []byte("example")},
+	})
+
+	//The file field is read before sname so the Domain Name is joined in that order.
+	file := []byte{byte(dhcp4.OptionSubnetMask), 4, 255, 255, 255, 0, byte(dhcp4.OptionDomainName), 1, '.', byte(dhcp4.End)}
+	copy(acknowledgement[108:236], file)
+
+	sname := []byte{byte(dhcp4.Pad), byte(dhcp4.OptionRouter), 4, 192, 168, 1, 254, byte(dhcp4.OptionDomainName), 3, 'c', 'o', 'm', byte(dhcp4.End)}
+	copy(acknowledgement[44:108], sname)
+
+	options := dhcp4client.ParseReplyOptions(acknowledgement)
+	if string(options[dhcp4.OptionDomainName]) != "example.com" {
+		test.Errorf("Domain Name:%q, expected %q", options[dhcp4.OptionDomainName], "example.com")
+	}
+
+	lease, err := dhcp4client.NewLease(acknowledgement, time.Now())
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	if !net.IP(lease.SubnetMask).Equal(net.IPv4(255, 255, 255, 0)) {
+		test.Errorf("SubnetMask:%v", lease.SubnetMask)
+	}
+	if len(lease.Routers) != 1 || !lease.Routers[0].Equal(net.IPv4(192, 168, 1, 254)) {
+		test.Errorf("Routers:%v", lease.Routers)
+	}
+	if lease.DomainName != "example.com" {
+		test.Errorf("DomainName:%q", lease.DomainName)
+	}
+}
+
+func Test_OptionOverloadIgnored(test *testing.T) {
+	//Without option 52 the file field is just a file name.
+	acknowledgement := testAcknowledgement(nil)
+	copy(acknowledgement[108:236], []byte{byte(dhcp4.OptionSubnetMask), 4, 255, 255, 255, 0})
+
+	if _, ok := dhcp4client.ParseReplyOptions(acknowledgement)[dhcp4.OptionSubnetMask]; ok {
+		test.Error("Subnet Mask parsed from the file field without Option Overload")
+	}
+}
+
+func Test_SplitOptions(test *testing.T) {
+	//Two DNS servers sent as two separate instances of the option.
+	acknowledgement := testAcknowledgement([]dhcp4.Option{
+		{Code: dhcp4.OptionDomainNameServer, Value: []byte{8, 8, 8, 8}},
+		{Code: dhcp4.OptionDomainNameServer, Value: []byte{8, 8, 4, 4}},
+	})
+
+	lease, err := dhcp4client.NewLease(acknowledgement, time.Now())
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	if len(lease.DNSServers) != 2 || !lease.DNSServers[1].Equal(net.IPv4(8, 8, 4, 4)) {
+		test.Errorf("DNSServers:%v", lease.DNSServers)
+	}
+}
@@ -2,8 +2,12 @@ package dhcp4client
 
 import (
 	"encoding/binary"
+	"errors"
 	"math/rand"
 	"net"
+	"os"
+	"sync"
+	"syscall"
 	"time"
 
 	"golang.org/x/sys/unix"
@@ -25,12 +29,20 @@ var (
 
 // abstracts AF_PACKET
 type packetSock struct {
-	fd      int
-	ifindex int
+	// the non-blocking socket is managed by the runtime poller so reads can be
+	// interrupted with a deadline.
+	file           *os.File
+	conn           syscall.RawConn
+	ifindex        int
+	maxMessageSize int
+	readTimeout    time.Duration
+
+	mu      sync.Mutex
+	servers map[string]net.HardwareAddr //The hardware address each server (or relay) last sent from.
 }
 
 func NewPacketSock(ifindex int) (*packetSock, error) {
-	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM, int(swap16(unix.ETH_P_IP)))
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM|unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC, int(swap16(unix.ETH_P_IP)))
 	if err != nil {
 		return nil, err
 	}
@@ -41,57 +53,171 @@ func NewPacketSock(ifindex int) (*packetSock, error) {
 	}
 
 	if err = unix.Bind(fd, &addr); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+
+	file := os.NewFile(uintptr(fd), "packet")
+	conn, err := file.SyscallConn()
+	if err != nil {
+		file.Close()
 		return nil, err
 	}
 
 	return &packetSock{
-		fd:      fd,
-		ifindex: ifindex,
+		file:           file,
+		conn:           conn,
+		ifindex:        ifindex,
+		maxMessageSize: MaxDHCPLen,
+		servers:        make(map[string]net.HardwareAddr),
 	}, nil
 }
 
 func (pc *packetSock) Close() error {
-	return unix.Close(pc.fd)
+	return pc.file.Close()
 }
 
 func (pc *packetSock) Write(packet []byte) error {
+	return pc.WriteTo(packet, nil, nil)
+}
+
+// WriteTo sends the packet to dstIP (255.255.255.255 if nil) at dstMAC, from
+// the packet's ciaddr. Without a dstMAC it's sent to the hardware address a
+// DHCP packet was last received from dstIP at, or broadcast if there hasn't
+// been one (e.g. the server is behind a relay).
+func (pc *packetSock) WriteTo(packet []byte, dstIP net.IP, dstMAC net.HardwareAddr) error {
+	if dstIP == nil {
+		dstIP = net.IPv4bcast
+	}
+	if dstMAC == nil {
+		dstMAC = pc.serverHardwareAddr(dstIP)
+	}
+
 	lladdr := unix.SockaddrLinklayer{
 		Ifindex:  pc.ifindex,
 		Protocol: swap16(unix.ETH_P_IP),
-		Halen:    uint8(len(bcastMAC)),
+		Halen:    uint8(len(dstMAC)),
+	}
+	copy(lladdr.Addr[:], dstMAC)
+
+	// ciaddr, zero until we have an address
+	srcIP := net.IPv4zero
+	if len(packet) >= 16 {
+		srcIP = net.IP(packet[12:16])
 	}
-	copy(lladdr.Addr[:], bcastMAC)
 
 	pkt := make([]byte, minIPHdrLen+udpHdrLen+len(packet))
 
-	fillIPHdr(pkt[0:minIPHdrLen], udpHdrLen+uint16(len(packet)))
+	fillIPHdr(pkt[0:minIPHdrLen], udpHdrLen+uint16(len(packet)), srcIP, dstIP)
 	fillUDPHdr(pkt[minIPHdrLen:minIPHdrLen+udpHdrLen], uint16(len(packet)))
 
 	// payload
 	copy(pkt[minIPHdrLen+udpHdrLen:len(pkt)], packet)
 
-	return unix.Sendto(pc.fd, pkt, 0, &lladdr)
+	var sendErr error
+	err := pc.conn.Write(func(fd uintptr) bool {
+		sendErr = unix.Sendto(int(fd), pkt, 0, &lladdr)
+		return sendErr != unix.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+	return sendErr
 }
 
 func (pc *packetSock) ReadFrom() ([]byte, net.IP, error) {
-	pkt := make([]byte, maxIPHdrLen+udpHdrLen+MaxDHCPLen)
-	n, _, err := unix.Recvfrom(pc.fd, pkt, 0)
-	if err != nil {
-		return nil, nil, err
+	pkt := make([]byte, maxIPHdrLen+udpHdrLen+pc.maxMessageSize)
+	for {
+		var n int
+		var from unix.Sockaddr
+		var recvErr error
+		err := pc.conn.Read(func(fd uintptr) bool {
+			// MSG_TRUNC returns the real length of the packet
+			n, from, recvErr = unix.Recvfrom(int(fd), pkt, unix.MSG_TRUNC)
+			return recvErr != unix.EAGAIN
+		})
+		if err == nil {
+			err = recvErr
+		}
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return nil, nil, &TimeoutError{Timeout: pc.readTimeout}
+			}
+			return nil, nil, err
+		}
+		if n > len(pkt) {
+			return nil, nil, &TruncatedError{Max: pc.maxMessageSize}
+		}
+
+		// skip anything too short to be, or that isn't, UDP
+		if !isUDP(pkt[:n]) {
+			continue
+		}
+
+		// IP hdr len
+		ihl := int(pkt[0]&0x0F) * 4
+		// Source IP address
+		src := net.IP(pkt[12:16])
+
+		if lladdr, ok := from.(*unix.SockaddrLinklayer); ok {
+			pc.learnServer(pkt[:n], lladdr)
+		}
+
+		return pkt[ihl+udpHdrLen : n], src, nil
+	}
+}
+
+// Is the IPv4 packet UDP, with room for its IP and UDP headers.
+func isUDP(pkt []byte) bool {
+	if len(pkt) < minIPHdrLen || pkt[9] != unix.IPPROTO_UDP {
+		return false
 	}
+	ihl := int(pkt[0]&0x0F) * 4
+	return ihl >= minIPHdrLen && len(pkt) >= ihl+udpHdrLen
+}
 
-	// IP hdr len
+// Remember the hardware address a DHCP packet from a server (UDP from port 67)
+// came from, so Renewals and Releases can be unicast back to it.
+func (pc *packetSock) learnServer(pkt []byte, from *unix.SockaddrLinklayer) {
+	if !isUDP(pkt) {
+		return
+	}
 	ihl := int(pkt[0]&0x0F) * 4
-	// Source IP address
-	src := net.IP(pkt[12:16])
+	if binary.BigEndian.Uint16(pkt[ihl:ihl+2]) != dstPort {
+		return
+	}
+	if from.Halen == 0 || int(from.Halen) > len(from.Addr) {
+		return
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.servers[net.IP(pkt[12:16]).String()] = append(net.HardwareAddr(nil), from.Addr[:from.Halen]...)
+}
 
-	return pkt[ihl+udpHdrLen : n], src, nil
+// The hardware address to send to ip at, broadcast unless it's a server we've
+// heard from.
+func (pc *packetSock) serverHardwareAddr(ip net.IP) net.HardwareAddr {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if mac, ok := pc.servers[ip.To4().String()]; ok {
+		return mac
+	}
+	return bcastMAC
+}
+
+func (pc *packetSock) SetMaxMessageSize(n int) error {
+	pc.maxMessageSize = n
+	return nil
 }
 
 func (pc *packetSock) SetReadTimeout(t time.Duration) error {
+	pc.readTimeout = t
+	return pc.file.SetReadDeadline(time.Now().Add(t))
+}
 
-	tv := unix.NsecToTimeval(t.Nanoseconds())
-	return unix.SetsockoptTimeval(pc.fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv)
+func (pc *packetSock) SetReadDeadline(t time.Time) error {
+	return pc.file.SetReadDeadline(t)
 }
 
 // compute's 1's complement checksum
@@ -112,7 +238,7 @@ func chksum(p []byte, csum []byte) {
 	csum[1] = uint8(s >> 8)
 }
 
-func fillIPHdr(hdr []byte, payloadLen uint16) {
+func fillIPHdr(hdr []byte, payloadLen uint16, src net.IP, dst net.IP) {
 	// version + IHL
 	hdr[0] = ip4Ver | (minIPHdrLen / 4)
 	// total length
@@ -125,8 +251,10 @@ func fillIPHdr(hdr []byte, payloadLen uint16) {
 	hdr[8] = 16
 	// Protocol
 	hdr[9] = unix.IPPROTO_UDP
+	// src IP
+	copy(hdr[12:16], src.To4())
 	// dst IP
-	copy(hdr[16:20], net.IPv4bcast.To4())
+	copy(hdr[16:20], dst.To4())
 	// compute IP hdr checksum
 	chksum(hdr[0:len(hdr)], hdr[10:12])
 }
@@ -0,0 +1,79 @@
+package dhcp4client
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+//An IPv4 UDP packet from src:srcPort.
+func testUDPPacket(src net.IP, srcPort uint16) []byte {
+	pkt := make([]byte, minIPHdrLen+udpHdrLen+optionsStart)
+	fillIPHdr(pkt[:minIPHdrLen], udpHdrLen+optionsStart, src, net.IPv4bcast)
+	pkt[minIPHdrLen] = byte(srcPort >> 8)
+	pkt[minIPHdrLen+1] = byte(srcPort)
+	return pkt
+}
+
+func Test_PacketSockLearnsServers(test *testing.T) {
+	pc := &packetSock{servers: make(map[string]net.HardwareAddr)}
+
+	server := net.IPv4(192, 168, 1, 1)
+	serverMAC := net.HardwareAddr{0x08, 0x00, 0x27, 0x00, 0xA8, 0x01}
+	other := net.IPv4(192, 168, 1, 2)
+	otherMAC := net.HardwareAddr{0x08, 0x00, 0x27, 0x00, 0xA8, 0x02}
+
+	from := func(mac net.HardwareAddr) *unix.SockaddrLinklayer {
+		lladdr := &unix.SockaddrLinklayer{Halen: uint8(len(mac))}
+		copy(lladdr.Addr[:], mac)
+		return lladdr
+	}
+
+	pc.learnServer(testUDPPacket(server, 67), from(serverMAC))
+	//Not from a DHCP server.
+	pc.learnServer(testUDPPacket(other, 53), from(otherMAC))
+
+	if mac := pc.serverHardwareAddr(server); !bytes.Equal(mac, serverMAC) {
+		test.Errorf("Server hardware address:%v, expected %v", mac, serverMAC)
+	}
+	if mac := pc.serverHardwareAddr(other); !bytes.Equal(mac, bcastMAC) {
+		test.Errorf("Other hardware address:%v, expected broadcast", mac)
+	}
+}
+
+func Test_IsUDP(test *testing.T) {
+	udp := testUDPPacket(net.IPv4(192, 168, 1, 1), 67)
+
+	tcp := testUDPPacket(net.IPv4(192, 168, 1, 1), 67)
+	tcp[9] = unix.IPPROTO_TCP
+
+	//The IHL says there are options which aren't there.
+	options := append([]byte(nil), udp[:minIPHdrLen+udpHdrLen]...)
+	options[0] = ip4Ver | (maxIPHdrLen / 4)
+
+	badIHL := append([]byte(nil), udp...)
+	badIHL[0] = ip4Ver | 1
+
+	tests := []struct {
+		name     string
+		pkt      []byte
+		expected bool
+	}{
+		{"UDP", udp, true},
+		{"UDP without a payload", udp[:minIPHdrLen+udpHdrLen], true},
+		{"TCP", tcp, false},
+		{"Empty", nil, false},
+		{"Short IP header", udp[:minIPHdrLen-1], false},
+		{"Short UDP header", udp[:minIPHdrLen+udpHdrLen-1], false},
+		{"Missing IP options", options, false},
+		{"IHL under the minimum", badIHL, false},
+	}
+
+	for _, t := range tests {
+		if isUDP(t.pkt) != t.expected {
+			test.Errorf("%s: isUDP:%v, expected %v", t.name, !t.expected, t.expected)
+		}
+	}
+}
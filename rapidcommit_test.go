@@ -0,0 +1,84 @@
+package dhcp4client_test
+
+import (
+	"testing"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+//Acknowledge Discovers which ask for Rapid Commit.
+func rapidCommit(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+	options := request.ParseOptions()
+	if _, ok := options[dhcp4client.OptionRapidCommit]; !ok || dhcp4.MessageType(options[dhcp4.OptionDHCPMessageType][0]) != dhcp4.Discover {
+		return []dhcp4.Packet{reply}
+	}
+
+	replyOptions := reply.ParseOptions()
+	acknowledgement := dhcp4.ReplyPacket(request, dhcp4.ACK, replyOptions[dhcp4.OptionServerIdentifier], reply.YIAddr(), 0, []dhcp4.Option{
+		{Code: dhcp4.OptionIPAddressLeaseTime, Value: replyOptions[dhcp4.OptionIPAddressLeaseTime]},
+		{Code: dhcp4client.OptionRapidCommit, Value: []byte{}},
+	})
+	return []dhcp4.Packet{acknowledgement}
+}
+
+func Test_RequestRapidCommit(test *testing.T) {
+	server := newTestServer()
+	server.Reply = rapidCommit
+
+	c := newTestClient(test, server)
+	c.SetOption(dhcp4client.RapidCommit(true))
+
+	success, acknowledgement, err := c.Request()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	if !acknowledgement.YIAddr().Equal(server.ClientIP) {
+		test.Errorf("Acknowledged %v, expected %v", acknowledgement.YIAddr(), server.ClientIP)
+	}
+
+	if sent := server.SentPackets(); len(sent) != 1 {
+		test.Errorf("Sent %d packets, expected only a DISCOVER", len(sent))
+	}
+}
+
+func Test_RequestRapidCommitFallback(test *testing.T) {
+	server := newTestServer()
+
+	c := newTestClient(test, server)
+	c.SetOption(dhcp4client.RapidCommit(true))
+
+	success, _, err := c.Request()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	sent := server.SentPackets()
+	if len(sent) != 2 {
+		test.Fatalf("Sent %d packets, expected a DISCOVER and a REQUEST", len(sent))
+	}
+	if _, ok := sent[0].ParseOptions()[dhcp4client.OptionRapidCommit]; !ok {
+		test.Error("DISCOVER is missing Rapid Commit")
+	}
+}
+
+func Test_RequestIgnoresUnrequestedRapidCommit(test *testing.T) {
+	server := newTestServer()
+	server.Reply = func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+		//Acknowledge the Discover even though we didn't ask for Rapid Commit.
+		request.AddOption(dhcp4client.OptionRapidCommit, []byte{})
+		return append(rapidCommit(request, reply), reply)
+	}
+
+	c := newTestClient(test, server)
+
+	success, _, err := c.Request()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	if sent := server.SentPackets(); len(sent) != 2 {
+		test.Errorf("Sent %d packets, expected a DISCOVER and a REQUEST", len(sent))
+	}
+}
@@ -0,0 +1,83 @@
+package dhcp4client
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/d2g/dhcp4"
+)
+
+//Only accept Acknowledgements (and NAKs) while REQUESTING or RENEWING from the
+//server the Request was sent to, i.e. with a matching Server Identifier
+//(option 54). On by default.
+func VerifyServerIdentifier(v bool) func(*Client) error {
+	return func(c *Client) error {
+		c.verifyServerID = v
+		return nil
+	}
+}
+
+//Check the reply (with the same xid) really answers the request.
+//Returns an error matching ErrServerMismatch if it's from the wrong server or
+//ErrMalformedReply if it can't be for us.
+func (c *Client) checkReply(request *dhcp4.Packet, reply dhcp4.Packet, replyOptions dhcp4.Options) error {
+	if reply.OpCode() != dhcp4.BootReply {
+		return malformed(fmt.Errorf("op %d isn't a BOOTREPLY", reply.OpCode()))
+	}
+
+	if !bytes.Equal(reply.CHAddr(), request.CHAddr()) {
+		return malformed(fmt.Errorf("chaddr %v isn't ours", reply.CHAddr()))
+	}
+
+	requestOptions := request.ParseOptions()
+
+	//https://tools.ietf.org/html/rfc6842 servers echo the Client Identifier,
+	//older ones may not send it at all.
+	if clientID, ok := replyOptions[dhcp4.OptionClientIdentifier]; ok && !bytes.Equal(clientID, requestOptions[dhcp4.OptionClientIdentifier]) {
+		return malformed(fmt.Errorf("client identifier %x isn't ours", clientID))
+	}
+
+	replyType := dhcp4.MessageType(replyOptions[dhcp4.OptionDHCPMessageType][0])
+	requestType := dhcp4.MessageType(requestOptions[dhcp4.OptionDHCPMessageType][0])
+
+	//https://tools.ietf.org/html/rfc2131#section-4.3.5 only an INFORM is
+	//Acknowledged without an address.
+	if replyType == dhcp4.ACK && requestType != dhcp4.Inform && reply.YIAddr().Equal(net.IPv4zero) {
+		return malformed(fmt.Errorf("DHCPACK without a yiaddr"))
+	}
+
+	//Only REQUESTING and RENEWING name the server.
+	server, ok := requestOptions[dhcp4.OptionServerIdentifier]
+	if c.verifyServerID && ok && !net.IP(replyOptions[dhcp4.OptionServerIdentifier]).Equal(net.IP(server)) {
+		return fmt.Errorf("%w: %v, expected %v", ErrServerMismatch, net.IP(replyOptions[dhcp4.OptionServerIdentifier]), net.IP(server))
+	}
+
+	return nil
+}
+
+//Log a reply that was ignored by checkReply, or couldn't be read.
+func (c *Client) logIgnored(source net.IP, err error) {
+	if c.logger != nil {
+		c.logger.Printf("dhcp4client: ignored reply from %v: %v", source, err)
+	}
+}
+
+//Why replies were ignored, preferring a reply from the wrong server to one we
+//couldn't read.
+func ignoredReply(mismatch error, truncated error) error {
+	if mismatch != nil {
+		return mismatch
+	}
+	return truncated
+}
+
+//The error for a wait that timed out, wrapping the reason for ignoring any
+//reply from the wrong server (or too large to read).
+func waitTimeout(timeout time.Duration, ignored error) error {
+	if ignored != nil {
+		return fmt.Errorf("%w: %w", &TimeoutError{Timeout: timeout}, ignored)
+	}
+	return &TimeoutError{Timeout: timeout}
+}
@@ -0,0 +1,107 @@
+package dhcp4client_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+//Send a spoofed reply to each Request ahead of the real one.
+func spoofRequests(spoof func(request dhcp4.Packet, reply dhcp4.Packet) dhcp4.Packet, real bool) func(dhcp4.Packet, dhcp4.Packet) []dhcp4.Packet {
+	return func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+		if dhcp4.MessageType(request.ParseOptions()[dhcp4.OptionDHCPMessageType][0]) != dhcp4.Request {
+			return []dhcp4.Packet{reply}
+		}
+		if !real {
+			return []dhcp4.Packet{spoof(request, reply)}
+		}
+		return []dhcp4.Packet{spoof(request, reply), reply}
+	}
+}
+
+func otherServer(request dhcp4.Packet, reply dhcp4.Packet) dhcp4.Packet {
+	return dhcp4.ReplyPacket(request, dhcp4.ACK, net.IPv4(192, 168, 1, 66).To4(), net.IPv4(192, 168, 1, 166), 0, nil)
+}
+
+func Test_VerifyServerIdentifier(test *testing.T) {
+	server := newTestServer()
+	server.Reply = spoofRequests(otherServer, true)
+	c := newTestClient(test, server)
+
+	success, acknowledgement, err := c.Request()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+	if !acknowledgement.YIAddr().Equal(server.ClientIP) {
+		test.Errorf("Accepted %v from the wrong server", acknowledgement.YIAddr())
+	}
+
+	success, acknowledgement, err = c.Renew(acknowledgement)
+	if err != nil || !success {
+		test.Fatalf("Renew Success:%v Error:%v\n", success, err)
+	}
+	if !acknowledgement.YIAddr().Equal(server.ClientIP) {
+		test.Errorf("Renewal accepted %v from the wrong server", acknowledgement.YIAddr())
+	}
+}
+
+func Test_VerifyServerIdentifierDisabled(test *testing.T) {
+	server := newTestServer()
+	server.Reply = spoofRequests(otherServer, false)
+	c := newTestClient(test, server)
+
+	if _, _, err := c.Request(); !errors.Is(err, dhcp4client.ErrServerMismatch) {
+		test.Fatalf("Error:%v, expected %v\n", err, dhcp4client.ErrServerMismatch)
+	}
+
+	c.SetOption(dhcp4client.VerifyServerIdentifier(false))
+	success, acknowledgement, err := c.Request()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+	if !acknowledgement.YIAddr().Equal(net.IPv4(192, 168, 1, 166)) {
+		test.Errorf("YIAddr:%v, expected the other server's", acknowledgement.YIAddr())
+	}
+}
+
+func Test_IgnoreInvalidAcknowledgements(test *testing.T) {
+	invalid := []struct {
+		name  string
+		spoof func(request dhcp4.Packet, reply dhcp4.Packet) dhcp4.Packet
+	}{
+		{"BOOTREQUEST", func(request dhcp4.Packet, reply dhcp4.Packet) dhcp4.Packet {
+			spoof := dhcp4.Packet(append([]byte(nil), reply...))
+			spoof[0] = byte(dhcp4.BootRequest)
+			return spoof
+		}},
+		{"chaddr", func(request dhcp4.Packet, reply dhcp4.Packet) dhcp4.Packet {
+			spoof := dhcp4.Packet(append([]byte(nil), reply...))
+			spoof.SetCHAddr(net.HardwareAddr{0, 1, 2, 3, 4, 5})
+			return spoof
+		}},
+		{"Client Identifier", func(request dhcp4.Packet, reply dhcp4.Packet) dhcp4.Packet {
+			return dhcp4.ReplyPacket(request, dhcp4.ACK, net.IPv4(192, 168, 1, 1).To4(), net.IPv4(192, 168, 1, 100), time.Hour, []dhcp4.Option{
+				{Code: dhcp4.OptionClientIdentifier, Value: []byte{1, 2, 3}},
+			})
+		}},
+		{"yiaddr", func(request dhcp4.Packet, reply dhcp4.Packet) dhcp4.Packet {
+			spoof := dhcp4.Packet(append([]byte(nil), reply...))
+			spoof.SetYIAddr(net.IPv4zero)
+			return spoof
+		}},
+	}
+
+	for _, i := range invalid {
+		server := newTestServer()
+		server.Reply = spoofRequests(i.spoof, false)
+		c := newTestClient(test, server)
+
+		if success, _, err := c.Request(); success || err == nil {
+			test.Errorf("%s: Request Success:%v Error:%v, expected the ACK to be ignored", i.name, success, err)
+		}
+	}
+}
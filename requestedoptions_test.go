@@ -0,0 +1,56 @@
+package dhcp4client_test
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+func Test_RequestedOptions(test *testing.T) {
+	requested := []dhcp4.OptionCode{dhcp4.OptionSubnetMask, dhcp4.OptionRouter, dhcp4client.OptionDomainSearch, dhcp4.OptionClasslessRouteFormat}
+
+	server := newTestServer()
+	c := newTestClient(test, server)
+	c.SetOption(dhcp4client.RequestedOptions(requested))
+
+	success, lease, err := c.RequestLease()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	if _, _, err := c.RenewLease(lease); err != nil {
+		test.Fatalf("Renew Error:%v\n", err)
+	}
+
+	if _, err := c.Inform(net.IPv4(192, 168, 1, 10)); err != nil {
+		test.Fatalf("Inform Error:%v\n", err)
+	}
+
+	expected := []byte{1, 3, 119, 121}
+	for _, sent := range server.SentPackets() {
+		if list := sent.ParseOptions()[dhcp4.OptionParameterRequestList]; !bytes.Equal(list, expected) {
+			test.Errorf("Parameter Request List:%v, expected %v", list, expected)
+		}
+	}
+
+	acknowledgement := lease.Acknowledgement()
+	release := c.ReleasePacket(&acknowledgement)
+	if _, ok := release.ParseOptions()[dhcp4.OptionParameterRequestList]; ok {
+		test.Error("Release must not contain a Parameter Request List")
+	}
+}
+
+func Test_MissingOptions(test *testing.T) {
+	acknowledgement := testAcknowledgement([]dhcp4.Option{
+		{Code: dhcp4.OptionSubnetMask, Value: []byte{255, 255, 255, 0}},
+	})
+
+	missing := dhcp4client.MissingOptions(acknowledgement, []dhcp4.OptionCode{dhcp4.OptionSubnetMask, dhcp4.OptionRouter, dhcp4client.OptionDomainSearch})
+	if expected := []dhcp4.OptionCode{dhcp4.OptionRouter, dhcp4client.OptionDomainSearch}; !reflect.DeepEqual(missing, expected) {
+		test.Errorf("Missing:%v, expected %v", missing, expected)
+	}
+}
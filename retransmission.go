@@ -0,0 +1,132 @@
+package dhcp4client
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/d2g/dhcp4"
+)
+
+//Decides how long to wait for a reply before sending a packet again.
+type RetransmissionPolicy interface {
+	//How long to wait for a reply to attempt (counting from 0),
+	//false to give up without sending.
+	Wait(attempt int) (time.Duration, bool)
+}
+
+//Doubles the wait between each attempt, randomised by +/- Jitter.
+type ExponentialBackoff struct {
+	Initial  time.Duration //Wait after the first attempt.
+	Max      time.Duration //Upper limit on the wait (before the Jitter is applied), 0 for none.
+	Jitter   time.Duration //Randomisation applied to each wait.
+	Attempts int           //Maximum number of times to send the packet.
+}
+
+//https://tools.ietf.org/html/rfc2131#section-4.1 explains:
+//
+//the delay before the first retransmission SHOULD be 4 seconds randomized by
+//the value of a uniform random number chosen from the range -1 to +1 [...]
+//The delay before the next retransmission SHOULD be 8 seconds randomized by
+//the value of a uniform number chosen from the range -1 to +1. The
+//retransmission delay SHOULD be doubled with subsequent retransmissions up to
+//a maximum of 64 seconds.
+func RFC2131Backoff(attempts int) ExponentialBackoff {
+	return ExponentialBackoff{
+		Initial:  time.Second * 4,
+		Max:      time.Second * 64,
+		Jitter:   time.Second,
+		Attempts: attempts,
+	}
+}
+
+func (b ExponentialBackoff) Wait(attempt int) (time.Duration, bool) {
+	if attempt >= b.Attempts {
+		return 0, false
+	}
+
+	//Double without overflowing, stopping at the Max.
+	wait := b.Initial
+	for i := 0; i < attempt && wait > 0 && (b.Max <= 0 || wait < b.Max); i++ {
+		if wait > math.MaxInt64/2 {
+			wait = math.MaxInt64
+			break
+		}
+		wait *= 2
+	}
+	if b.Max > 0 && wait > b.Max {
+		wait = b.Max
+	}
+
+	if b.Jitter > 0 {
+		jitter := time.Duration(rand.Int63n(int64(b.Jitter)*2+1)) - b.Jitter
+		if jitter > 0 && wait > math.MaxInt64-jitter {
+			jitter = math.MaxInt64 - wait
+		}
+		wait += jitter
+	}
+
+	if wait <= 0 {
+		wait = time.Millisecond
+	}
+	return wait, true
+}
+
+//Broadcast a packet and wait for the reply.
+//If there's a RetransmissionPolicy the packet is resent (with the same xid and
+//an updated secs field) each time the wait times out.
+//Stops as soon as the context is done.
+func (c *Client) exchange(ctx context.Context, packet dhcp4.Packet, wait func(context.Context, *dhcp4.Packet, time.Duration) (dhcp4.Packet, error)) (dhcp4.Packet, error) {
+	return c.exchangeTo(ctx, packet, nil, wait)
+}
+
+//exchange, sending the packet to the server at dst (see SendPacketTo).
+func (c *Client) exchangeTo(ctx context.Context, packet dhcp4.Packet, dst net.IP, wait func(context.Context, *dhcp4.Packet, time.Duration) (dhcp4.Packet, error)) (dhcp4.Packet, error) {
+	if c.retransmission == nil {
+		if err := c.SendPacketTo(packet, dst); err != nil {
+			return dhcp4.Packet{}, err
+		}
+		return wait(ctx, &packet, c.timeout)
+	}
+
+	start := time.Now()
+	var lastErr error
+
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return dhcp4.Packet{}, err
+		}
+
+		timeout, ok := c.retransmission.Wait(attempt)
+		if !ok {
+			if lastErr == nil {
+				lastErr = &TimeoutError{Timeout: time.Since(start)}
+			}
+			return dhcp4.Packet{}, lastErr
+		}
+
+		secs := time.Since(start) / time.Second
+		if secs > math.MaxUint16 {
+			secs = math.MaxUint16
+		}
+		secsBytes := make([]byte, 2)
+		binary.BigEndian.PutUint16(secsBytes, uint16(secs))
+		packet.SetSecs(secsBytes)
+
+		if err := c.SendPacketTo(packet, dst); err != nil {
+			return dhcp4.Packet{}, err
+		}
+
+		reply, err := wait(ctx, &packet, timeout)
+		if err == nil {
+			return reply, nil
+		}
+		if !isTimeout(err) || ctx.Err() != nil {
+			return dhcp4.Packet{}, err
+		}
+		lastErr = err
+	}
+}
@@ -0,0 +1,119 @@
+package dhcp4client_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+func Test_RFC2131Backoff(test *testing.T) {
+	backoff := dhcp4client.RFC2131Backoff(6)
+
+	expected := []time.Duration{4, 8, 16, 32, 64, 64}
+	for attempt, e := range expected {
+		wait, ok := backoff.Wait(attempt)
+		if !ok {
+			test.Fatalf("Attempt %d gave up", attempt)
+		}
+
+		if wait < (e-1)*time.Second || wait > (e+1)*time.Second {
+			test.Errorf("Attempt %d waited %v, expected %v +/- 1s", attempt, wait, e*time.Second)
+		}
+	}
+
+	if _, ok := backoff.Wait(len(expected)); ok {
+		test.Errorf("Attempt %d should have given up", len(expected))
+	}
+}
+
+//Without a Max the wait keeps doubling, and doesn't overflow.
+func Test_ExponentialBackoffNoMax(test *testing.T) {
+	backoff := dhcp4client.ExponentialBackoff{Initial: time.Second * 4, Attempts: 100}
+
+	expected := []time.Duration{4, 8, 16, 32, 64, 128}
+	for attempt, e := range expected {
+		wait, ok := backoff.Wait(attempt)
+		if !ok {
+			test.Fatalf("Attempt %d gave up", attempt)
+		}
+		if wait != e*time.Second {
+			test.Errorf("Attempt %d waited %v, expected %v", attempt, wait, e*time.Second)
+		}
+	}
+
+	previous := time.Duration(0)
+	for attempt := 0; attempt < backoff.Attempts; attempt++ {
+		wait, _ := backoff.Wait(attempt)
+		if wait < previous {
+			test.Fatalf("Attempt %d waited %v, less than the %v before", attempt, wait, previous)
+		}
+		previous = wait
+	}
+}
+
+//Drop the first n DISCOVERs.
+func dropDiscovers(n int) func(dhcp4.Packet, dhcp4.Packet) []dhcp4.Packet {
+	return func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+		if dhcp4.MessageType(request.ParseOptions()[dhcp4.OptionDHCPMessageType][0]) == dhcp4.Discover && n > 0 {
+			n--
+			return nil
+		}
+		return []dhcp4.Packet{reply}
+	}
+}
+
+func Test_RequestRetransmits(test *testing.T) {
+	server := newTestServer()
+	server.Reply = dropDiscovers(2)
+
+	c := newTestClient(test, server)
+	c.SetOption(dhcp4client.Retransmission(dhcp4client.ExponentialBackoff{Initial: time.Millisecond * 600, Max: time.Second, Attempts: 3}))
+
+	success, _, err := c.Request()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	sent := server.SentPackets()
+	if len(sent) != 4 {
+		test.Fatalf("Sent %d packets, expected 3 DISCOVERs and a REQUEST", len(sent))
+	}
+
+	for i, discover := range sent[:3] {
+		if !bytes.Equal(discover.XId(), sent[0].XId()) {
+			test.Errorf("DISCOVER %d has xid %v, expected %v", i, discover.XId(), sent[0].XId())
+		}
+	}
+
+	//The third DISCOVER goes out 1.6 seconds after the first.
+	if secs := binary.BigEndian.Uint16(sent[2].Secs()); secs != 1 {
+		test.Errorf("Third DISCOVER has secs %d, expected 1", secs)
+	}
+}
+
+func Test_RequestRetransmissionGivesUp(test *testing.T) {
+	server := newTestServer()
+	server.Reply = dropDiscovers(3)
+
+	c := newTestClient(test, server)
+	c.SetOption(dhcp4client.Retransmission(dhcp4client.ExponentialBackoff{Initial: time.Millisecond * 10, Max: time.Millisecond * 20, Attempts: 3}))
+
+	_, _, err := c.Request()
+	if err == nil {
+		test.Fatal("Request succeeded without an offer")
+	}
+
+	var timeoutError *dhcp4client.TimeoutError
+	if !errors.As(err, &timeoutError) {
+		test.Errorf("Error:%v, expected a *TimeoutError", err)
+	}
+
+	if sent := server.SentPackets(); len(sent) != 3 {
+		test.Errorf("Sent %d packets, expected 3 DISCOVERs", len(sent))
+	}
+}
@@ -0,0 +1,131 @@
+package dhcp4client_test
+
+import (
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/d2g/dhcp4"
+)
+
+//A ConnectionInt backed by an in memory DHCP server so the client can be
+//exercised without a network.
+type testServer struct {
+	ServerIP  net.IP
+	ClientIP  net.IP
+	LeaseTime time.Duration
+	Options   []dhcp4.Option
+
+	//Optional hook to replace or drop (by returning nil) the server's replies.
+	Reply func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet
+
+	mu        sync.Mutex
+	replies   chan dhcp4.Packet
+	timeout   time.Duration
+	interrupt chan struct{}
+	Sent      []dhcp4.Packet
+}
+
+func newTestServer() *testServer {
+	return &testServer{
+		ServerIP:  net.IPv4(192, 168, 1, 1).To4(),
+		ClientIP:  net.IPv4(192, 168, 1, 100).To4(),
+		LeaseTime: time.Hour,
+		replies:   make(chan dhcp4.Packet, 16),
+		timeout:   time.Second,
+		interrupt: make(chan struct{}, 1),
+	}
+}
+
+func (s *testServer) Close() error {
+	return nil
+}
+
+func (s *testServer) Write(packet []byte) error {
+	request := dhcp4.Packet(append([]byte(nil), packet...))
+
+	s.mu.Lock()
+	s.Sent = append(s.Sent, request)
+	s.mu.Unlock()
+
+	options := request.ParseOptions()
+	if len(options[dhcp4.OptionDHCPMessageType]) < 1 {
+		return nil
+	}
+
+	var reply dhcp4.Packet
+	switch dhcp4.MessageType(options[dhcp4.OptionDHCPMessageType][0]) {
+	case dhcp4.Discover:
+		reply = dhcp4.ReplyPacket(request, dhcp4.Offer, s.ServerIP, s.ClientIP, s.LeaseTime, s.Options)
+	case dhcp4.Request:
+		reply = dhcp4.ReplyPacket(request, dhcp4.ACK, s.ServerIP, s.ClientIP, s.LeaseTime, s.Options)
+	case dhcp4.Inform:
+		reply = dhcp4.ReplyPacket(request, dhcp4.ACK, s.ServerIP, nil, 0, s.Options)
+	default:
+		return nil
+	}
+
+	replies := []dhcp4.Packet{reply}
+	if s.Reply != nil {
+		replies = s.Reply(request, reply)
+	}
+
+	for _, r := range replies {
+		s.replies <- r
+	}
+	return nil
+}
+
+func (s *testServer) ReadFrom() ([]byte, net.IP, error) {
+	s.mu.Lock()
+	timeout := s.timeout
+	s.mu.Unlock()
+
+	select {
+	case reply := <-s.replies:
+		return reply, s.ServerIP, nil
+	case <-time.After(timeout):
+		return nil, nil, syscall.EAGAIN
+	case <-s.interrupt:
+		return nil, nil, syscall.EAGAIN
+	}
+}
+
+func (s *testServer) SetReadTimeout(t time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeout = t
+
+	//Forget any earlier interruption.
+	select {
+	case <-s.interrupt:
+	default:
+	}
+	return nil
+}
+
+//Only deadlines in the past are supported, interrupting ReadFrom.
+func (s *testServer) SetReadDeadline(t time.Time) error {
+	if time.Until(t) > 0 {
+		return s.SetReadTimeout(time.Until(t))
+	}
+
+	select {
+	case s.interrupt <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+//The packets the client has sent so far.
+func (s *testServer) SentPackets() []dhcp4.Packet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]dhcp4.Packet(nil), s.Sent...)
+}
+
+//Reply with a NAK instead of whatever the server would have sent.
+func nak(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+	return []dhcp4.Packet{dhcp4.ReplyPacket(request, dhcp4.NAK, reply.ParseOptions()[dhcp4.OptionServerIdentifier], nil, 0, nil)}
+}
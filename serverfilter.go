@@ -0,0 +1,88 @@
+package dhcp4client
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/d2g/dhcp4"
+)
+
+//Don't accept packets from servers in these networks.
+//A packet is rejected if its source address, siaddr or Server Identifier
+//(option 54) is in any of them.
+func DenyServers(n []*net.IPNet) func(*Client) error {
+	return func(c *Client) error {
+		c.denyServers = n
+		return nil
+	}
+}
+
+//Only accept packets from servers in these networks.
+//The Server Identifier (option 54), or the source address if there isn't one,
+//must be in one of them. Servers which are also denied are still rejected.
+func AllowServers(n []*net.IPNet) func(*Client) error {
+	return func(c *Client) error {
+		c.allowServers = n
+		return nil
+	}
+}
+
+//The number of packets answering our requests which were rejected by the
+//IgnoreServers, DenyServers or AllowServers filters.
+func (c *Client) RejectedPackets() uint64 {
+	return c.rejected.Load()
+}
+
+//Check a packet from source against the server filters, counting and logging
+//it if it's rejected.
+//Returns true if the packet should be ignored.
+func (c *Client) rejectServer(source net.IP, packet dhcp4.Packet, options dhcp4.Options) bool {
+	reason := c.filterServer(source, packet, options)
+	if reason == "" {
+		return false
+	}
+
+	c.rejected.Add(1)
+	if c.logger != nil {
+		c.logger.Printf("dhcp4client: rejected packet from %v (server identifier %v, siaddr %v): %s", source, net.IP(options[dhcp4.OptionServerIdentifier]), packet.SIAddr(), reason)
+	}
+	return true
+}
+
+//Why a packet is rejected by the server filters, "" if it's accepted.
+func (c *Client) filterServer(source net.IP, packet dhcp4.Packet, options dhcp4.Options) string {
+	serverIdentifier := net.IP(options[dhcp4.OptionServerIdentifier])
+
+	for _, ip := range []net.IP{source, packet.SIAddr(), serverIdentifier} {
+		if len(ip) == 0 || ip.IsUnspecified() {
+			continue
+		}
+
+		for _, ignoreServer := range c.ignoreServers {
+			if ip.Equal(ignoreServer) {
+				return fmt.Sprintf("%v is ignored", ip)
+			}
+		}
+
+		for _, n := range c.denyServers {
+			if n.Contains(ip) {
+				return fmt.Sprintf("%v is in denied network %v", ip, n)
+			}
+		}
+	}
+
+	if len(c.allowServers) == 0 {
+		return ""
+	}
+
+	server := serverIdentifier
+	if len(server) == 0 {
+		server = source
+	}
+	for _, n := range c.allowServers {
+		if n.Contains(server) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%v is not in an allowed network", server)
+}
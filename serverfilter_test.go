@@ -0,0 +1,95 @@
+package dhcp4client_test
+
+import (
+	"bytes"
+	"log"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+//Offers from a rogue server then the real one.
+func rogueServer(rogue net.IP) func(dhcp4.Packet, dhcp4.Packet) []dhcp4.Packet {
+	return func(request dhcp4.Packet, reply dhcp4.Packet) []dhcp4.Packet {
+		if dhcp4.MessageType(request.ParseOptions()[dhcp4.OptionDHCPMessageType][0]) != dhcp4.Discover {
+			return []dhcp4.Packet{reply}
+		}
+
+		rogueOffer := dhcp4.ReplyPacket(request, dhcp4.Offer, rogue, net.IPv4(10, 0, 0, 100), 0, nil)
+		return []dhcp4.Packet{rogueOffer, reply}
+	}
+}
+
+func mustParseCIDR(test *testing.T, s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	return n
+}
+
+func Test_IgnoreServers(test *testing.T) {
+	server := newTestServer()
+	server.Reply = rogueServer(net.IPv4(10, 0, 0, 1))
+
+	c := newTestClient(test, server)
+	c.SetOption(dhcp4client.IgnoreServers([]net.IP{net.IPv4(10, 0, 0, 1)}))
+
+	success, acknowledgement, err := c.Request()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+	if !acknowledgement.YIAddr().Equal(server.ClientIP) {
+		test.Errorf("Leased %v from the ignored server", acknowledgement.YIAddr())
+	}
+	if c.RejectedPackets() != 1 {
+		test.Errorf("Rejected %d packets, expected 1", c.RejectedPackets())
+	}
+}
+
+func Test_DenyServers(test *testing.T) {
+	server := newTestServer()
+	server.Reply = rogueServer(net.IPv4(10, 0, 0, 1))
+
+	var logged bytes.Buffer
+	c := newTestClient(test, server)
+	c.SetOption(dhcp4client.DenyServers([]*net.IPNet{mustParseCIDR(test, "10.0.0.0/8")}), dhcp4client.Log(log.New(&logged, "", 0)))
+
+	success, acknowledgement, err := c.Request()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+	if !acknowledgement.YIAddr().Equal(server.ClientIP) {
+		test.Errorf("Leased %v from the denied server", acknowledgement.YIAddr())
+	}
+	if c.RejectedPackets() != 1 {
+		test.Errorf("Rejected %d packets, expected 1", c.RejectedPackets())
+	}
+	if !strings.Contains(logged.String(), "10.0.0.1") {
+		test.Errorf("Log:%q doesn't mention the denied server", logged.String())
+	}
+}
+
+func Test_AllowServers(test *testing.T) {
+	server := newTestServer()
+	server.Reply = rogueServer(net.IPv4(10, 0, 0, 1))
+
+	c := newTestClient(test, server)
+	c.SetOption(dhcp4client.AllowServers([]*net.IPNet{mustParseCIDR(test, "192.168.1.0/24")}))
+
+	success, acknowledgement, err := c.Request()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+	if !acknowledgement.YIAddr().Equal(server.ClientIP) {
+		test.Errorf("Leased %v from a server that isn't allowed", acknowledgement.YIAddr())
+	}
+
+	c.SetOption(dhcp4client.AllowServers([]*net.IPNet{mustParseCIDR(test, "172.16.0.0/12")}))
+	if success, _, err := c.Request(); success || err == nil {
+		test.Errorf("Request Success:%v Error:%v, expected no allowed offers", success, err)
+	}
+}
@@ -0,0 +1,126 @@
+package dhcp4client_test
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+//A testServer which records where packets sent with WriteTo were addressed.
+type unicastServer struct {
+	*testServer
+
+	mu           sync.Mutex
+	destinations []net.IP
+}
+
+func (s *unicastServer) WriteTo(packet []byte, dstIP net.IP, dstMAC net.HardwareAddr) error {
+	s.mu.Lock()
+	s.destinations = append(s.destinations, dstIP)
+	s.mu.Unlock()
+
+	return s.Write(packet)
+}
+
+func Test_UnicastRenewAndRelease(test *testing.T) {
+	server := &unicastServer{testServer: newTestServer()}
+
+	m, err := net.ParseMAC("08-00-27-00-A8-E8")
+	if err != nil {
+		test.Fatalf("MAC Error:%v\n", err)
+	}
+
+	c, err := dhcp4client.New(dhcp4client.HardwareAddr(m), dhcp4client.Connection(server), dhcp4client.Timeout(time.Millisecond*200))
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	success, acknowledgement, err := c.Request()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+	if len(server.destinations) != 0 {
+		test.Errorf("Request unicast to %v, expected it to be broadcast", server.destinations)
+	}
+
+	success, acknowledgement, err = c.Renew(acknowledgement)
+	if err != nil || !success {
+		test.Fatalf("Renew Success:%v Error:%v\n", success, err)
+	}
+
+	if err := c.Release(acknowledgement); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	if len(server.destinations) != 2 || !server.destinations[0].Equal(server.ServerIP) || !server.destinations[1].Equal(server.ServerIP) {
+		test.Errorf("Unicast to %v, expected the Renew and Release to go to %v", server.destinations, server.ServerIP)
+	}
+
+	sent := server.SentPackets()
+	if release := sent[len(sent)-1]; dhcp4.MessageType(release.ParseOptions()[dhcp4.OptionDHCPMessageType][0]) != dhcp4.Release {
+		test.Error("Release wasn't sent")
+	}
+}
+
+//Rebinding is broadcast even when the connection can unicast.
+func Test_UnicastRebind(test *testing.T) {
+	server := &unicastServer{testServer: newTestServer()}
+
+	m, err := net.ParseMAC("08-00-27-00-A8-E8")
+	if err != nil {
+		test.Fatalf("MAC Error:%v\n", err)
+	}
+
+	c, err := dhcp4client.New(dhcp4client.HardwareAddr(m), dhcp4client.Connection(server), dhcp4client.Timeout(time.Millisecond*200))
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	success, lease, err := c.RequestLease()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	success, _, err = c.Rebind(lease)
+	if err != nil || !success {
+		test.Fatalf("Rebind Success:%v Error:%v\n", success, err)
+	}
+
+	if len(server.destinations) != 1 || !server.destinations[0].Equal(net.IPv4bcast) {
+		test.Errorf("Sent to %v, expected the Rebind to go to %v", server.destinations, net.IPv4bcast)
+	}
+}
+
+func Test_InetSockWriteTo(test *testing.T) {
+	listener, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	defer listener.Close()
+
+	//The remote address is broadcast, WriteTo should only use its port.
+	port := listener.LocalAddr().(*net.UDPAddr).Port
+	c, err := dhcp4client.NewInetSock(dhcp4client.SetLocalAddr(net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}), dhcp4client.SetRemoteAddr(net.UDPAddr{IP: net.IPv4bcast, Port: port}))
+	if err != nil {
+		test.Fatalf("Client Connection Generation:%v\n", err)
+	}
+	defer c.Close()
+
+	if err := c.WriteTo([]byte("renew"), net.IPv4(127, 0, 0, 1), nil); err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	listener.SetReadDeadline(time.Now().Add(time.Second))
+	buffer := make([]byte, 16)
+	n, _, err := listener.ReadFromUDP(buffer)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if string(buffer[:n]) != "renew" {
+		test.Errorf("Received %q", buffer[:n])
+	}
+}
@@ -0,0 +1,81 @@
+package dhcp4client
+
+import (
+	"fmt"
+
+	"github.com/d2g/dhcp4"
+)
+
+//Vendor-Specific Information (option 43) sub-options keyed by their code.
+type VendorOptions map[byte][]byte
+
+//Split Vendor-Specific Information (option 43) into its encapsulated
+//sub-options https://tools.ietf.org/html/rfc2132#section-8.4
+//Sub-options which appear more than once are concatenated.
+func ParseVendorOptions(b []byte) (VendorOptions, error) {
+	options := make(VendorOptions)
+
+	for len(b) > 0 {
+		code := b[0]
+		if code == byte(dhcp4.End) {
+			break
+		}
+		if code == byte(dhcp4.Pad) {
+			b = b[1:]
+			continue
+		}
+
+		if len(b) < 2 || len(b) < 2+int(b[1]) {
+			return nil, fmt.Errorf("vendor sub-option %d is truncated", code)
+		}
+
+		options[code] = append(options[code], b[2:2+int(b[1])]...)
+		b = b[2+int(b[1]):]
+	}
+
+	return options, nil
+}
+
+//Parses the value of a Vendor-Specific Information sub-option.
+type VendorOptionParser func(value []byte) (interface{}, error)
+
+//Decodes Vendor-Specific Information with the parsers registered for its
+//sub-options.
+type VendorDecoder struct {
+	parsers map[byte]VendorOptionParser
+}
+
+func NewVendorDecoder() *VendorDecoder {
+	return &VendorDecoder{
+		parsers: make(map[byte]VendorOptionParser),
+	}
+}
+
+//Parse the sub-option with code using p.
+func (d *VendorDecoder) Register(code byte, p VendorOptionParser) {
+	d.parsers[code] = p
+}
+
+//Decode Vendor-Specific Information.
+//Sub-options with a registered parser are returned parsed, the rest as []byte.
+func (d *VendorDecoder) Decode(b []byte) (map[byte]interface{}, error) {
+	options, err := ParseVendorOptions(b)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := make(map[byte]interface{}, len(options))
+	for code, value := range options {
+		parser, ok := d.parsers[code]
+		if !ok {
+			decoded[code] = value
+			continue
+		}
+
+		if decoded[code], err = parser(value); err != nil {
+			return nil, fmt.Errorf("vendor sub-option %d: %w", code, err)
+		}
+	}
+
+	return decoded, nil
+}
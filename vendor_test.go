@@ -0,0 +1,80 @@
+package dhcp4client_test
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/d2g/dhcp4"
+	"github.com/d2g/dhcp4client"
+)
+
+func Test_VendorClassAndOptions(test *testing.T) {
+	server := newTestServer()
+	server.Options = []dhcp4.Option{
+		{Code: dhcp4.OptionVendorSpecificInformation, Value: []byte{1, 4, 10, 0, 0, 1, 0, 2, 3, 'a', 'b', 'c', 255}},
+	}
+
+	c := newTestClient(test, server)
+	c.SetOption(dhcp4client.VendorClass("example-device"))
+
+	success, lease, err := c.RequestLease()
+	if err != nil || !success {
+		test.Fatalf("Request Success:%v Error:%v\n", success, err)
+	}
+
+	for _, sent := range server.SentPackets() {
+		if class := sent.ParseOptions()[dhcp4.OptionVendorClassIdentifier]; string(class) != "example-device" {
+			test.Errorf("Vendor Class:%q", class)
+		}
+	}
+
+	decoder := dhcp4client.NewVendorDecoder()
+	decoder.Register(1, func(value []byte) (interface{}, error) {
+		if len(value) != 4 {
+			return nil, errors.New("expected an address")
+		}
+		return net.IP(value), nil
+	})
+
+	decoded, err := decoder.Decode(lease.VendorSpecific)
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+
+	if ip, ok := decoded[1].(net.IP); !ok || !ip.Equal(net.IPv4(10, 0, 0, 1)) {
+		test.Errorf("Sub-option 1:%v, expected 10.0.0.1", decoded[1])
+	}
+	if raw, ok := decoded[2].([]byte); !ok || !bytes.Equal(raw, []byte("abc")) {
+		test.Errorf("Sub-option 2:%v, expected abc", decoded[2])
+	}
+}
+
+func Test_ParseVendorOptions(test *testing.T) {
+	options, err := dhcp4client.ParseVendorOptions([]byte{1, 2, 'a', 'b', 1, 1, 'c'})
+	if err != nil {
+		test.Fatalf("Error:%v\n", err)
+	}
+	if string(options[1]) != "abc" {
+		test.Errorf("Sub-option 1:%q, expected repeated sub-options to be concatenated", options[1])
+	}
+
+	if _, err := dhcp4client.ParseVendorOptions([]byte{1, 4, 'a'}); err == nil {
+		test.Error("Expected an error for a truncated sub-option")
+	}
+}
+
+//A sub-option parser's error is wrapped.
+func Test_VendorDecoderError(test *testing.T) {
+	errBadAddress := errors.New("expected an address")
+
+	decoder := dhcp4client.NewVendorDecoder()
+	decoder.Register(1, func(value []byte) (interface{}, error) {
+		return nil, errBadAddress
+	})
+
+	if _, err := decoder.Decode([]byte{1, 1, 0}); !errors.Is(err, errBadAddress) {
+		test.Errorf("Error:%v, expected %v", err, errBadAddress)
+	}
+}